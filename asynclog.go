@@ -20,6 +20,10 @@
 //	   asynclog.Print(msg string)
 //	   asynclog.Debug(msg string) // includes file and line number
 //
+//	// Leveled messages, filtered by asynclog.SetLevel(l Level):
+//	   asynclog.Info(msg string)  // "[INFO] msg"
+//	   asynclog.Warnf(format string, args ...any)
+//
 //	// Stop the logger to ensure all messages are consumed before the program exits:
 //	   asynclog.Stop() // defer after Start()
 //
@@ -47,7 +51,6 @@
 package asynclog
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -56,62 +59,86 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	buffer     = 100
-	messages   chan string
-	workers              = 15
-	isStarted            = false
-	output     io.Writer = os.Stdout // Change type to io.Writer
 	debugCache sync.Map
+	newline    = true
+	terminator = "\n"
+
+	debugCacheLimit = 0 // unlimited
+	debugCacheLen   atomic.Int64
+
+	includeHostPID  = false
+	debugElapsed    = false
+	sequenceNumbers = false
+
+	timestamps = false
+	timeFormat = defaultTimeFormat
 )
 
+const defaultTimeFormat = "2006/01/02 15:04:05.000000"
+
+// hostPID returns the "host[pid] " prefix, looked up once
+var hostPID = sync.OnceValue(func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "[" + strconv.Itoa(os.Getpid()) + "] "
+})
+
 // DebugInfo represents debugging information that includes the file name, line number, and a string message.
 // This struct is used to store and convey detailed debugging information within the logging system.
+//
+// Cached entries are shared by every goroutine logging from the same line, they are
+// fully built by debugInfo() before being stored and never modified afterwards.
 type DebugInfo struct {
 	pc   uintptr
 	file string
 	line int
-	str  string
+	str  string // "file:line", precomputed
 }
 
 func (info *DebugInfo) String() string {
-	if info.str == "" {
-		info.str = fmt.Sprintf("%s:%d", info.file, info.line)
-	}
 	return info.str
 }
 
 // Sets the buffer limit to the messages channel. Default is 100.
 //
+// The channels are allocated by the first Start() and kept by Stop(): restarting the logger with
+// the same buffer size, and the same number of channels set by SetSharding(), reuses them without
+// allocating. They are owned by the logger and never closed, Stop() signals the workers separately,
+// so a message sent while the logger is stopped is never sent on a closed channel.
+//
 // Must be called before
 //
 //	Start()
 //
 // If the logger is already started, this function does nothing.
 func SetBuffer(b int) {
-	if isStarted {
-		return
-	}
-	buffer = b
+	std.SetBuffer(b)
 }
 
 // Takes an io.Writer to redirect logs to a file or other destination. Default is
 //
 //	os.Stdout //the console
 //
+// When set to io.Discard the workers only drain the messages channel without any buffering or writes.
+// A nil writer is treated as io.Discard.
+//
+// The workers never write to w at the same time and always write whole lines, so writers
+// that are not safe for concurrent use, such as a *bytes.Buffer, can be used as is.
+//
 // Has to be called before
 //
 //	Start()
 //
 // If the logger is already started, this function does nothing.
 func SetOutput(w io.Writer) {
-	if isStarted {
-		return
-	}
-	output = w
+	std.SetOutput(w)
 }
 
 // Sets the number of worker goroutines for message consumption.
@@ -124,17 +151,196 @@ func SetOutput(w io.Writer) {
 //
 // If the logger is already started, this function does nothing.
 func SetWorkers(w int) {
-	if isStarted {
+	std.SetWorkers(w)
+}
+
+// maxAutoWorkers caps SetWorkersAuto(), beyond that the workers mostly wait on the output lock
+const maxAutoWorkers = 32
+
+// Sets the number of workers to runtime.GOMAXPROCS(0) at Start(), capped at 32,
+// to adapt the pool to the parallelism of the host.
+//
+// SetWorkersAuto() and SetWorkers() override each other, the last call wins.
+// With a single slow output, such as a file, more workers rarely help: the batches are
+// written one at a time.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetWorkersAuto() {
+	std.SetWorkersAuto()
+}
+
+// Sets whether the logger writes a reproducible byte stream, for golden file tests. Default is false.
+//
+// A single worker is used regardless of SetWorkers(), messages are written in the order they
+// are sent, Error and Fatal messages included, and nothing is written on a timer: only when
+// the batch buffer is full, on Flush() and on Stop().
+//
+//	asynclog.SetOutput(&buf)
+//	asynclog.SetDeterministic(true)
+//	asynclog.Start()
+//	runScenario()
+//	asynclog.Stop()
+//	// compare buf with testdata/scenario.golden
+//
+// This gives up most of the throughput of the worker pool, do not use it in production.
+// Combine with SetClock() for timestamps.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetDeterministic(b bool) {
+	std.SetDeterministic(b)
+}
+
+// Sets whether the workers append a '\n' after each message. Default is true.
+//
+// Turn this off for writers that frame messages themselves, such as syslog.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetAppendNewline(b bool) {
+	if std.isStarted {
+		return
+	}
+	newline = b
+}
+
+// Sets the line terminator the workers append after each message instead of '\n'. Default is "\n".
+//
+// For consumers expecting "\r\n" or a custom record separator:
+//
+//	asynclog.SetLineTerminator("\r\n")
+//
+// Nothing is appended with SetAppendNewline(false).
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetLineTerminator(s string) {
+	if std.isStarted {
+		return
+	}
+	terminator = s
+}
+
+// Sets whether every message is prefixed with the hostname and process ID, e.g. "web-3[4182] msg". Default is false.
+//
+// Useful when many replicas write to a shared sink. Both are looked up once by Start().
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetIncludeHostPID(b bool) {
+	if std.isStarted {
+		return
+	}
+	includeHostPID = b
+}
+
+// Sets whether every message is prefixed with a sequence number, e.g. "#000123 msg". Default is false.
+//
+// Numbers are taken when a message is sent, in call order, and each Logger counts on its own from 1.
+// A gap in the output reveals dropped messages, and numbers out of order show how the workers
+// reordered messages sent concurrently. Numbers below 1000000 are padded to 6 digits.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetSequenceNumbers(b bool) {
+	if std.isStarted {
+		return
+	}
+	sequenceNumbers = b
+}
+
+// Sets whether every message is prefixed with the time it was sent. Default is false.
+//
+// The time is taken when Print(), Debug(), etc. is called, not when a worker writes the
+// message, so it reflects when the event happened even though messages are batched.
+// The format is set with SetTimeFormat().
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetTimestamps(b bool) {
+	if std.isStarted {
 		return
 	}
-	workers = w
+	timestamps = b
 }
 
-// Returns the file and line number of the caller.
+// Sets the layout used by SetTimestamps(), see time.Layout. Default is
+//
+//	"2006/01/02 15:04:05.000000"
+//
+// If the logger is already started, this function does nothing.
+func SetTimeFormat(layout string) {
+	if std.isStarted {
+		return
+	}
+	timeFormat = layout
+}
+
+// Sets whether Debug() messages are prefixed with the time elapsed since Start(), e.g. "+1.234567s file.go:42 msg". Default is false.
+//
+// The elapsed time comes from the monotonic clock, so it is not affected by wall clock changes
+// and orders messages precisely within a single process. Off by default to avoid the formatting cost.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetDebugElapsed(b bool) {
+	if std.isStarted {
+		return
+	}
+	debugElapsed = b
+}
+
+// Sets the maximum number of call sites kept in the cache used by Debug() and Caller(). Default is 0, unlimited.
+//
+// The cache holds one entry per unique call site and is never evicted, which is only a problem for
+// long running programs with a very large number of call sites. When the limit is exceeded the
+// whole cache is cleared and rebuilt on following calls. A small limit bounds memory but causes
+// more cache misses, each costing a runtime lookup and an allocation.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetDebugCacheLimit(n int) {
+	if std.isStarted {
+		return
+	}
+	debugCacheLimit = n
+}
+
+// Returns the file and line number of a caller on the stack.
+//
+// skip is the number of stack frames to ascend, with 0 identifying the caller of debugInfo.
 //
 // Uses the debugCache to avoid recomputing the same info.
-func debugInfo() *DebugInfo {
-	pc, file, line, ok := runtime.Caller(2)
+func debugInfo(skip int) *DebugInfo {
+	pc, file, line, ok := runtime.Caller(skip + 1)
 	if !ok {
 		return nil
 	}
@@ -149,14 +355,39 @@ func debugInfo() *DebugInfo {
 		pc:   pc,
 		file: file,
 		line: line,
+		str:  file + ":" + strconv.Itoa(line),
+	}
+	if _, loaded := debugCache.LoadOrStore(pc, info); !loaded && debugCacheLimit > 0 {
+		if debugCacheLen.Add(1) > int64(debugCacheLimit) {
+			debugCache.Clear()
+			debugCacheLen.Store(0)
+		}
 	}
-	debugCache.Store(pc, info)
 	return info
 }
 
+// Caller returns the "file.go:line" of a caller without sending anything to the logger.
+//
+// skip is the number of stack frames to ascend, with 0 identifying the caller of Caller.
+// This is the same location Debug() would print when called from that frame.
+//
+//	asynclog.Caller(0) // the line calling Caller
+//	asynclog.Caller(1) // the line calling the function that called Caller
+//
+// The result is cached per call site like Debug(). Returns "" if the caller cannot be determined.
+func Caller(skip int) string {
+	info := debugInfo(skip + 1)
+	if info == nil {
+		return ""
+	}
+	return info.String()
+}
+
 // Start initializes the logger by setting up the message channel, debug cache, and worker goroutines for concurrent message processing.
 //
 // If the logger is already started, it returns immediately. This function must be called before sending any messages to the logger.
+// A logger stopped by Stop() can be started again, reusing its channels, see SetBuffer().
+// The first time that happens a warning is written to os.Stderr, since any configuration set in between is ignored.
 //
 // Example:
 //
@@ -169,27 +400,49 @@ func debugInfo() *DebugInfo {
 //	//some work while calling these thread safe logging functions:
 //	asynclog.Print(s string)
 //	asynclog.Debug(s string)
+//
+// An invalid configuration, such as SetWorkers(0), is clamped to the nearest sane value
+// and a warning is written to os.Stderr. Use StartErr() to handle it yourself instead.
 func Start() {
-	if isStarted {
-		return
-	}
-	messages = make(chan string, buffer)
-	debugCache = sync.Map{}
-	isStarted = true
-	for i := 0; i < workers; i++ {
-		go consumeMessages()
-	}
+	std.Start()
 }
 
-// Closes the messages channel for a graceful shutdown
+// StartErr is like Start() but returns an error instead of clamping an invalid configuration.
+//
+// When an error is returned the logger is not started. The error wraps ErrInvalidConfig.
+func StartErr() error {
+	return std.StartErr()
+}
+
+// Signals the workers to write the remaining messages and waits for them to exit for a graceful shutdown
 //
 // Does nothing if Start() was not called
 func Stop() {
-	if !isStarted {
-		return
-	}
-	isStarted = false
-	close(messages)
+	std.Stop()
+}
+
+// StopErr is like Stop() but returns ErrNotStarted if the logger was not running,
+// to catch shutdown sequences that stop it twice or before starting it:
+//
+//	if err := asynclog.StopErr(); err != nil {
+//		panic("logger stopped out of order: " + err.Error())
+//	}
+func StopErr() error {
+	return std.StopErr()
+}
+
+// StartTime returns when the logger was last started, or the zero time if it is not started.
+func StartTime() time.Time {
+	return std.StartTime()
+}
+
+// Uptime returns how long the logger has been running since Start(), or 0 if it is not started.
+//
+// Handy for periodic status lines:
+//
+//	asynclog.Infof("uptime: %s", asynclog.Uptime().Round(time.Minute))
+func Uptime() time.Duration {
+	return std.Uptime()
 }
 
 // Convert any type to string efficiently
@@ -201,23 +454,61 @@ func toString(v any) string {
 		return strconv.Itoa(val)
 	case int64:
 		return strconv.FormatInt(val, 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int16:
+		return strconv.FormatInt(int64(val), 10)
+	case int8:
+		return strconv.FormatInt(int64(val), 10)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10)
 	case float64:
 		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case []byte:
+		return string(val)
 	case bool:
 		return strconv.FormatBool(val)
 	case error:
 		return val.Error()
+	case time.Duration:
+		return val.String() // 1.5s rather than 1500000000
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
 	default:
+		if reflectFormat {
+			if s, ok := reflectString(val); ok {
+				return s
+			}
+		}
 		return fmt.Sprint(val)
 	}
 }
 
 // Print sends a string to the messages channel if the logger is started.
 func Print(msg string) {
-	if !isStarted {
-		return
-	}
-	messages <- msg
+	std.Print(msg)
+}
+
+// PrintTo sends a string to the logger to be written to w instead of the configured output.
+//
+// Useful to route a single message, like a critical alert, to another sink such as os.Stderr
+// without reconfiguring the logger. The message is written to w on its own, right away,
+// and is not batched with other messages.
+//
+// Writes to w are serialized with the other PrintTo() writes of the logger, so w does not have
+// to be safe for concurrent use as long as nothing else writes to it meanwhile.
+func PrintTo(w io.Writer, msg string) {
+	std.PrintTo(w, msg)
 }
 
 // PrintArgs takes and sends a string to the messages channel if the logger is started.
@@ -232,54 +523,53 @@ func Print(msg string) {
 //
 // This function is not faster than Print(). It is an ease of use function for concatenating multiple strings to the logger.
 func PrintArgs(args ...any) {
-	if !isStarted {
-		return
-	}
+	std.PrintArgs(args...)
+}
 
-	if len(args) == 1 {
-		messages <- toString(args[0])
-		return
-	}
+// PrintMany sends every message of msgs to the logger, in order, like calling Print() for each of them.
+//
+// Useful to flush a set of precomputed lines at once, e.g. from a transaction log. The started
+// check and the clock are only done once for the whole slice. Each message still goes through
+// the channel on its own, so the drop policy applies to every message separately.
+func PrintMany(msgs []string) {
+	std.PrintMany(msgs)
+}
 
-	sargs := make([]string, len(args))
-	for i, arg := range args {
-		sargs[i] = toString(arg)
-	}
+// printedOnce holds the call sites of PrintOnce() that already sent their message
+var printedOnce sync.Map
 
-	totalLen := 0
-	for _, s := range sargs {
-		totalLen += len(s)
+// PrintOnce sends msg to the logger the first time it is called from a given line,
+// later calls from the same line are ignored. The classic "warn once":
+//
+//	func legacyHandler() {
+//		asynclog.PrintOnce("legacyHandler is deprecated, use handler")
+//		...
+//	}
+//
+// The call site is remembered for the lifetime of the program, across restarts of the logger.
+// Calls dropped because the logger is not started do not count.
+func PrintOnce(msg string) {
+	if !std.accepting() {
+		return
 	}
-
-	var sb strings.Builder
-	sb.Grow(totalLen)
-	for _, arg := range args {
-		sb.WriteString(toString(arg))
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return
 	}
-
-	messages <- sb.String()
+	if _, seen := printedOnce.LoadOrStore(pc, struct{}{}); seen {
+		return
+	}
+	std.send(msg)
 }
 
-// Sends a string to the logger prepended with the file and line number of the caller.
-//
-// If the logger is not started, the message is ignored.
+// Join converts args to strings like PrintArgs(), joins them with sep and sends the result to the logger.
 //
-// FIXME: add % increase from the benchmarks
-// Tip: fmt.Sprintf() _% slower than a basic string concatenation.
+//	asynclog.Join(" | ", "GET", "/users", 200, 12*time.Millisecond)
+//	// Output: GET | /users | 200 | 12ms
 //
-// Thread safe!
-func Debug(msg string) {
-	if !isStarted {
-		return
-	}
-	info := debugInfo()
-
-	if info != nil {
-		msg = info.String() + " " + msg
-	} else {
-		msg = "ISSUE DETERMINING RUNTIME CALLER: " + msg
-	}
-	messages <- msg
+// It is strings.Join with type conversion, for a one-off separator.
+func Join(sep string, args ...any) {
+	std.Join(sep, args...)
 }
 
 var builderPool = sync.Pool{
@@ -288,80 +578,67 @@ var builderPool = sync.Pool{
 	},
 }
 
-// TODO: more improvements
-func consumeMessages() {
-	const (
-		batchSize     = 256       // Larger batches for better throughput
-		bufferSize    = 1024 * 64 // 64KB buffer
-		flushInterval = 500 * time.Millisecond
-	)
-
-	// Pre-allocate buffer
-	buf := make([]byte, 0, bufferSize)
-	w := bufio.NewWriterSize(output, bufferSize)
-	defer w.Flush()
-
-	timer := time.NewTimer(flushInterval)
-	defer timer.Stop()
-
-	for {
-		select {
-		case msg, ok := <-messages:
-			if !ok {
-				if len(buf) > 0 {
-					w.Write(buf)
-					w.Flush()
-				}
-				return
-			}
-
-			buf = append(buf, msg...)
-			buf = append(buf, '\n')
-
-			if len(buf) >= batchSize {
-				w.Write(buf)
-				w.Flush()
-				buf = buf[:0]
-				timer.Reset(flushInterval)
-			}
+// SetHere sets the string message to be used by the Here() function.
+//
+// If the logger is already started, this function does nothing.
+func SetHere(msg string) {
+	if std.isStarted {
+		return
+	}
+	here = msg
+}
 
-		case <-timer.C:
-			if len(buf) > 0 {
-				w.Write(buf)
-				w.Flush()
-				buf = buf[:0]
-			}
-			timer.Reset(flushInterval)
-		}
+// SetHereLevel sets the level Here() and DebugHere() are filtered at. Default is DebugLevel.
+//
+// With the default, raising the minimum level with SetLevel() above DebugLevel silences them.
+//
+// If the logger is already started, this function does nothing.
+func SetHereLevel(l Level) {
+	if std.isStarted {
+		return
 	}
+	hereLevel = l
 }
 
-// SetHere sets the string message to be used by the Here() function.
+// SetHereGlobalCounter sets whether Here() and DebugHere() append a program wide hit counter,
+// e.g. "Here [42]". Default is false.
+//
+// Every hit from any goroutine increments the same counter, so the numbers show the exact
+// order the markers were reached in concurrent code.
 //
 // If the logger is already started, this function does nothing.
-func SetHere(msg string) {
-	if isStarted {
+func SetHereGlobalCounter(b bool) {
+	if std.isStarted {
 		return
 	}
-	here = msg
+	hereCounter = b
 }
 
 var (
-	here = "Here"
+	here        = "Here"
+	hereLevel   = DebugLevel
+	hereCounter = false
+	hereHits    atomic.Uint64
 )
 
+// hereMsg returns the Here() message, with the global counter if enabled
+func hereMsg() string {
+	if !hereCounter {
+		return here
+	}
+	return here + " [" + strconv.FormatUint(hereHits.Add(1), 10) + "]"
+}
+
 // Here() sends the default "Here" message to the messages channel if the logger is started.
+//
+// Filtered at the level set by SetHereLevel(). See SetHereSummary() to count the hits instead.
 func Here() {
-	if !isStarted {
+	if !std.enabled(hereLevel) {
 		return
 	}
-	messages <- here
-}
-
-// DebugHere() is a convenience function that calls Debug() with whatever is set to SetHere() default "Here".
-func DebugHere() {
-	if !isStarted {
+	if hereSummary {
+		hereSummaryHits.Add(1)
 		return
 	}
-	Debug(here)
+	std.sendLevel(hereLevel, hereMsg())
 }
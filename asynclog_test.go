@@ -0,0 +1,703 @@
+package asynclog_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	asynclog "github.com/ninesl/asynclog-go"
+)
+
+func TestSetOutputNil(t *testing.T) {
+	asynclog.SetOutput(nil)
+	defer asynclog.SetOutput(os.Stdout)
+
+	asynclog.Start()
+	asynclog.Print("discarded")
+	if err := asynclog.Flush(); err != nil {
+		t.Fatalf("Flush() = %v, want nil", err)
+	}
+	asynclog.Stop()
+}
+
+func TestConcurrentBytesBuffer(t *testing.T) {
+	const (
+		goroutines = 50
+		perRoutine = 200
+	)
+
+	var buf bytes.Buffer
+	asynclog.SetOutput(&buf)
+	asynclog.SetWorkers(15)
+	defer asynclog.SetOutput(os.Stdout)
+
+	asynclog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perRoutine; i++ {
+				asynclog.Print("goroutine " + strconv.Itoa(g) + " line " + strconv.Itoa(i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := asynclog.Flush(); err != nil {
+		t.Fatalf("Flush() = %v, want nil", err)
+	}
+	asynclog.Stop()
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if !strings.HasPrefix(line, "goroutine ") || strings.Count(line, " ") != 3 {
+			t.Fatalf("corrupted line %q", line)
+		}
+		seen[line] = true
+	}
+	if len(seen) != goroutines*perRoutine {
+		t.Fatalf("got %d distinct lines, want %d", len(seen), goroutines*perRoutine)
+	}
+}
+
+func TestNamedLoggersIndependent(t *testing.T) {
+	var auditBuf, appBuf bytes.Buffer
+
+	audit := asynclog.Get("test-audit")
+	audit.SetOutput(&auditBuf)
+	audit.SetBuffer(10)
+	app := asynclog.Get("test-app")
+	app.SetOutput(&appBuf)
+	app.SetLevel(asynclog.WarnLevel)
+
+	audit.Start()
+	app.Start()
+	audit.Info("user 42 logged in")
+	app.Info("filtered")
+	app.Warn("disk almost full")
+	audit.Stop()
+	app.Stop()
+
+	if got, want := auditBuf.String(), "[INFO] user 42 logged in\n"; got != want {
+		t.Errorf("audit output = %q, want %q", got, want)
+	}
+	if got, want := appBuf.String(), "[WARN] disk almost full\n"; got != want {
+		t.Errorf("app output = %q, want %q", got, want)
+	}
+	if asynclog.GetLevel() != asynclog.DebugLevel {
+		t.Errorf("default level = %v, want DEBUG", asynclog.GetLevel())
+	}
+}
+
+// slowWriter is a bytes.Buffer that takes a while to write, so the workers fall behind
+type slowWriter struct{ bytes.Buffer }
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return w.Buffer.Write(p)
+}
+
+func TestOverflowSpill(t *testing.T) {
+	const messages = 500
+
+	dir := t.TempDir()
+	var out slowWriter
+	l := asynclog.NewLogger()
+	l.SetOutput(&out)
+	l.SetBuffer(1)
+	l.SetWorkers(1)
+	l.SetOverflowSpill(dir)
+
+	l.Start()
+	for i := 0; i < messages; i++ {
+		l.Print("line " + strconv.Itoa(i))
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush() = %v, want nil", err)
+	}
+	l.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != messages {
+		t.Fatalf("got %d lines, want %d", len(lines), messages)
+	}
+	for i, line := range lines {
+		if want := "line " + strconv.Itoa(i); line != want {
+			t.Fatalf("line %d = %q, want %q", i, line, want)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("spill file not removed by Stop(): %v", entries)
+	}
+}
+
+func TestRedactKeys(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var buf bytes.Buffer
+	asynclog.SetOutput(&buf)
+	asynclog.RedactKeys("password", "token")
+
+	asynclog.Start()
+	asynclog.Log(asynclog.InfoLevel, "login", asynclog.String("user", "bob"), asynclog.String("Password", "hunter2"))
+	asynclog.With("TOKEN", "abc").Info("refresh")
+	asynclog.Stop()
+
+	for _, want := range []string{"[INFO] login user=bob Password=***\n", "[INFO] refresh TOKEN=***\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output %q does not contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestWithMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&buf)
+	l.SetWorkers(1)
+
+	l.Start()
+	l.With("reqid", "4f2a").Print("first\nsecond")
+	l.Stop()
+
+	if want := "first reqid=4f2a\nsecond\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStackTraceLevel(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var buf bytes.Buffer
+	asynclog.SetOutput(&buf)
+	asynclog.SetWorkers(1)
+	asynclog.SetDeterministic(true)
+	asynclog.SetStackTraceLevel(asynclog.ErrorLevel)
+
+	asynclog.Start()
+	asynclog.With("reqid", "4f2a").Error("payment failed")
+	asynclog.ErrorWithStack(errors.New("no such file"), "loading config")
+	asynclog.Stop()
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "[ERROR] payment failed reqid=4f2a\n") {
+		t.Errorf("fields not on the first line, before the trace:\n%s", out)
+	}
+	// One trace per message, ErrorWithStack() does not get a second one
+	if n := strings.Count(out, ".TestStackTraceLevel"); n != 2 {
+		t.Errorf("got %d traces, want 2:\n%s", n, out)
+	}
+}
+
+func TestStopErr(t *testing.T) {
+	l := asynclog.NewLogger()
+	if err := l.StopErr(); !errors.Is(err, asynclog.ErrNotStarted) {
+		t.Fatalf("StopErr() before Start() = %v, want ErrNotStarted", err)
+	}
+	l.Start()
+	if err := l.StopErr(); err != nil {
+		t.Fatalf("StopErr() = %v, want nil", err)
+	}
+	if err := l.StopErr(); !errors.Is(err, asynclog.ErrNotStarted) {
+		t.Fatalf("second StopErr() = %v, want ErrNotStarted", err)
+	}
+}
+
+func TestUseMiddlewares(t *testing.T) {
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&buf)
+	l.SetWorkers(1)
+	l.Use(
+		func(next func(string)) func(string) {
+			return func(line string) {
+				if line != "healthcheck" {
+					next(line)
+				}
+			}
+		},
+		func(next func(string)) func(string) {
+			return func(line string) {
+				for _, part := range strings.Split(line, ";") {
+					next(part)
+				}
+			}
+		},
+		func(next func(string)) func(string) {
+			return func(line string) { next(strings.ToUpper(line)) }
+		},
+	)
+
+	l.Start()
+	l.Print("healthcheck")
+	l.Print("a;b")
+	l.Stop()
+
+	if got, want := buf.String(), "A\nB\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestGlobalRateLimitDrops(t *testing.T) {
+	const messages = 100
+
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&buf)
+	l.SetBuffer(messages)
+	l.SetDropPolicy(asynclog.DropNewest)
+	l.SetGlobalRateLimit(10)
+
+	l.Start()
+	for i := 0; i < messages; i++ {
+		l.Print("line " + strconv.Itoa(i))
+	}
+	l.Stop()
+
+	written := strings.Count(buf.String(), "\n")
+	if written < 10 || written > 20 {
+		t.Errorf("wrote %d lines, want about 10", written)
+	}
+	if got := int(l.Dropped()); got != messages-written {
+		t.Errorf("Dropped() = %d, want %d", got, messages-written)
+	}
+}
+
+func TestGlobalRateLimitClock(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var (
+		mu    sync.Mutex
+		clock = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+	asynclog.SetClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	})
+
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&buf)
+	l.SetBuffer(32)
+	l.SetDropPolicy(asynclog.DropNewest)
+	l.SetGlobalRateLimit(10)
+
+	l.Start()
+	for i := 0; i < 15; i++ {
+		l.Print("burst " + strconv.Itoa(i))
+	}
+	l.Flush()
+	mu.Lock()
+	clock = clock.Add(time.Second)
+	mu.Unlock()
+	for i := 0; i < 5; i++ {
+		l.Print("refilled " + strconv.Itoa(i))
+	}
+	l.Stop()
+
+	if got := strings.Count(buf.String(), "\n"); got != 15 {
+		t.Errorf("wrote %d lines, want 15", got)
+	}
+	if got := l.Dropped(); got != 5 {
+		t.Errorf("Dropped() = %d, want 5", got)
+	}
+}
+
+// gateWriter blocks every write until open is closed
+type gateWriter struct {
+	open chan struct{}
+	buf  bytes.Buffer
+}
+
+func (gw *gateWriter) Write(p []byte) (int, error) {
+	<-gw.open
+	return gw.buf.Write(p)
+}
+
+func TestMaxQueuedBytesDrops(t *testing.T) {
+	gw := &gateWriter{open: make(chan struct{})}
+	l := asynclog.NewLogger()
+	l.SetOutput(gw)
+	l.SetWorkers(1)
+	l.SetMaxQueuedBytes(10)
+	l.SetDropPolicy(asynclog.DropNewest)
+
+	l.Start()
+	// Opens the writer even if sending waits for room, so the test fails instead of hanging
+	timer := time.AfterFunc(time.Second, func() { close(gw.open) })
+	for i := 0; i < 5; i++ {
+		l.Print("0123456789")
+	}
+	dropped := l.Dropped()
+	if timer.Stop() {
+		close(gw.open)
+	}
+	l.Stop()
+
+	if dropped != 4 {
+		t.Errorf("Dropped() = %d while the writer was blocked, want 4", dropped)
+	}
+	if got := strings.Count(gw.buf.String(), "\n"); got != 1 {
+		t.Errorf("wrote %d lines, want 1", got)
+	}
+}
+
+// fakeTB records the calls NewTestWriter() makes to a test
+type fakeTB struct {
+	lines   []string
+	cleanup func()
+}
+
+func (tb *fakeTB) Log(args ...any)  { tb.lines = append(tb.lines, fmt.Sprint(args...)) }
+func (tb *fakeTB) Cleanup(f func()) { tb.cleanup = f }
+
+func TestNewTestWriter(t *testing.T) {
+	var _ asynclog.TestLogger = t
+
+	tb := &fakeTB{}
+	w := asynclog.NewTestWriter(tb)
+	io.WriteString(w, "first\nsecond\n")
+	tb.cleanup()
+	io.WriteString(w, "after the test\n")
+
+	if !slices.Equal(tb.lines, []string{"first", "second"}) {
+		t.Errorf("logged %q, want the lines written before cleanup", tb.lines)
+	}
+}
+
+// recordCollector keeps the records passed to it
+type recordCollector struct{ records []asynclog.Record }
+
+func (rc *recordCollector) WriteRecord(r asynclog.Record) error {
+	rc.records = append(rc.records, r)
+	return nil
+}
+
+func TestRecordWriter(t *testing.T) {
+	var rc recordCollector
+	l := asynclog.NewLogger()
+	l.SetRecordWriter(&rc)
+	l.SetWorkers(1)
+	l.SetDeterministic(true)
+
+	l.Start()
+	l.Warn("disk almost full")
+	l.Log(asynclog.InfoLevel, "request done", asynclog.Int("status", 200))
+	l.With("reqid", "4f2a").Print("plain")
+	l.Stop()
+
+	if len(rc.records) != 3 {
+		t.Fatalf("got %d records, want 3", len(rc.records))
+	}
+	if r := rc.records[0]; r.Level != asynclog.WarnLevel || r.Msg != "disk almost full" || r.Time.IsZero() {
+		t.Errorf("Warn record = %+v", r)
+	}
+	if r := rc.records[1]; r.Msg != "request done" || len(r.Fields) != 1 {
+		t.Errorf("Log record = %+v", r)
+	}
+	if r := rc.records[2]; r.Level != asynclog.InfoLevel || r.Msg != "plain" || len(r.Fields) != 1 ||
+		r.Fields[0].Key() != "reqid" || r.Fields[0].Value() != "4f2a" {
+		t.Errorf("Print record = %+v", r)
+	}
+}
+
+func TestRecordWriterOwnWriter(t *testing.T) {
+	var rc recordCollector
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetRecordWriter(&rc)
+	l.SetStderrThreshold(asynclog.FatalLevel)
+	l.SetWorkers(1)
+	l.SetDeterministic(true)
+
+	l.Start()
+	l.PrintTo(&buf, "audit")
+	l.Log(asynclog.FatalLevel, "giving up")
+	l.Stop()
+
+	if len(rc.records) != 2 || rc.records[0].Msg != "audit" || rc.records[1].Level != asynclog.FatalLevel {
+		t.Errorf("records = %+v, want the PrintTo() and Fatal messages", rc.records)
+	}
+	if buf.String() != "audit\n" {
+		t.Errorf("PrintTo() wrote %q, want it still written to its writer", buf.String())
+	}
+}
+
+func TestRecordWriterRedact(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var rc recordCollector
+	asynclog.SetRecordWriter(&rc)
+	asynclog.SetWorkers(1)
+	asynclog.RedactKeys("token")
+
+	asynclog.Start()
+	asynclog.With("user", "bob", "attempt", 2).Log(asynclog.WarnLevel, "login", asynclog.String("token", "abc"))
+	asynclog.New().Level(asynclog.InfoLevel).Field("Token", "abc").Msg("refresh")
+	asynclog.Stop()
+
+	if len(rc.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(rc.records))
+	}
+	want := [][]string{{"token=***", "user=bob", "attempt=2"}, {"Token=***"}}
+	for i, r := range rc.records {
+		var got []string
+		for _, f := range r.Fields {
+			got = append(got, f.String())
+		}
+		if !slices.Equal(got, want[i]) {
+			t.Errorf("record %d fields = %q, want %q", i, got, want[i])
+		}
+	}
+	if v := rc.records[0].Fields[2].Value(); v != int64(2) {
+		t.Errorf("attempt value = %#v, want int64(2)", v)
+	}
+}
+
+func TestSharding(t *testing.T) {
+	const (
+		goroutines = 20
+		perRoutine = 100
+	)
+
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&buf)
+	l.SetWorkers(4)
+	l.SetSharding(8) // capped to the 4 workers
+
+	l.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perRoutine; i++ {
+				l.Print("line")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush() = %v, want nil", err)
+	}
+	l.Stop()
+
+	if got := strings.Count(buf.String(), "line\n"); got != goroutines*perRoutine {
+		t.Errorf("got %d lines, want %d", got, goroutines*perRoutine)
+	}
+}
+
+// restartAlloc returns the bytes allocated by restarting l, which has already been started once
+func restartAlloc(l *asynclog.Logger) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	l.Start()
+	l.Stop()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestRestartKeepsChannel(t *testing.T) {
+	const buffer = 100_000
+
+	l := asynclog.NewLogger()
+	l.SetOutput(io.Discard)
+	l.SetBuffer(buffer)
+	l.SetWorkers(1)
+
+	l.Start()
+	l.Stop()
+	if got := restartAlloc(l); got > 8*buffer {
+		t.Errorf("restart allocated %d bytes, the channel was not kept", got)
+	}
+
+	l.SetBuffer(2 * buffer)
+	if got := restartAlloc(l); got < 8*buffer {
+		t.Errorf("restart with a new buffer size allocated %d bytes, want a new channel", got)
+	}
+}
+
+func TestShardingRestart(t *testing.T) {
+	// Megabytes of channels, far more than the worker buffers and the rest of a restart allocate
+	const buffer = 100_000
+
+	l := asynclog.NewLogger()
+	l.SetOutput(io.Discard)
+	l.SetBuffer(buffer)
+	l.SetWorkers(4)
+	l.SetSharding(4)
+
+	l.Start()
+	l.Stop()
+	if got := restartAlloc(l); got > 8*buffer {
+		t.Errorf("restart allocated %d bytes, the channels were not kept", got)
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("sink unreachable") }
+
+func TestFailoverWriter(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var backup bytes.Buffer
+	var failures int
+	asynclog.SetErrorHandler(func(error) { failures++ })
+
+	l := asynclog.NewLogger()
+	l.SetOutput(failingWriter{})
+	l.SetFailoverWriter(&backup)
+	l.SetWorkers(1)
+
+	l.Start()
+	for i := 0; i < 5; i++ {
+		l.Print("line " + strconv.Itoa(i))
+		l.Flush()
+	}
+	l.Stop()
+
+	if got := strings.Count(backup.String(), "\n"); got != 5 {
+		t.Errorf("secondary got %d lines, want 5:\n%s", got, backup.String())
+	}
+	if failures != 3 {
+		t.Errorf("reported %d errors, want 3 before the switch", failures)
+	}
+}
+
+// downWriter fails every write while down is set
+type downWriter struct {
+	down atomic.Bool
+	buf  bytes.Buffer
+}
+
+func (w *downWriter) Write(p []byte) (int, error) {
+	if w.down.Load() {
+		return 0, errors.New("sink unreachable")
+	}
+	return w.buf.Write(p)
+}
+
+func TestFailoverWriterProbe(t *testing.T) {
+	t.Cleanup(asynclog.Reset)
+	var (
+		mu    sync.Mutex
+		clock = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+	asynclog.SetClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	})
+	asynclog.SetErrorHandler(func(error) {})
+
+	var backup bytes.Buffer
+	primary := &downWriter{}
+	primary.down.Store(true)
+	l := asynclog.NewLogger()
+	l.SetOutput(primary)
+	l.SetFailoverWriter(&backup)
+	l.SetWorkers(1)
+
+	l.Start()
+	for i := 0; i < 3; i++ {
+		l.Print("outage " + strconv.Itoa(i))
+		l.Flush()
+	}
+	primary.down.Store(false)
+	l.Print("before the probe")
+	l.Flush()
+	mu.Lock()
+	clock = clock.Add(5 * time.Second)
+	mu.Unlock()
+	l.Print("probe")
+	l.Flush()
+	l.Print("back")
+	l.Stop()
+
+	if want := "probe\nback\n"; primary.buf.String() != want {
+		t.Errorf("primary got %q, want %q", primary.buf.String(), want)
+	}
+	if got := strings.Count(backup.String(), "\n"); got != 4 {
+		t.Errorf("secondary got %d lines, want 4:\n%s", got, backup.String())
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe to read while the workers write to it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPrintWithDone(t *testing.T) {
+	var out lockedBuffer
+	l := asynclog.NewLogger()
+	l.SetOutput(&out)
+	l.SetDeterministic(true) // writes nothing until Flush(), done has to stay open until then
+
+	l.Start()
+	defer l.Stop()
+	l.Print("before")
+	done := l.PrintWithDone("important")
+	select {
+	case <-done:
+		t.Fatal("done closed before the message was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	l.Flush()
+	<-done
+	if got := out.String(); !strings.Contains(got, "important\n") {
+		t.Errorf("output = %q after done, want the message", got)
+	}
+}
+
+func TestPrintToConcurrent(t *testing.T) {
+	const (
+		goroutines = 20
+		perRoutine = 50
+	)
+
+	var buf bytes.Buffer
+	l := asynclog.NewLogger()
+	l.SetOutput(io.Discard)
+	l.SetWorkers(4)
+
+	l.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perRoutine; i++ {
+				l.PrintTo(&buf, "alert")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Stop()
+
+	if got := strings.Count(buf.String(), "alert\n"); got != goroutines*perRoutine {
+		t.Errorf("got %d lines, want %d", got, goroutines*perRoutine)
+	}
+}
@@ -0,0 +1,98 @@
+package asynclog
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// BatchWriter accumulates writes and passes them on to an underlying writer in larger batches.
+type BatchWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	size     int
+	interval time.Duration
+	buf      []byte
+	timer    *time.Timer // pending interval write, nil when buf is empty
+	err      error       // error of the last interval write, returned by the next call
+}
+
+// NewBatchWriter returns a writer that sends what is written to it to w in batches of at least
+// size bytes, or whatever has accumulated after interval, whichever comes first.
+//
+// The workers batch per worker and write at least every 500ms, which suits a local file.
+// A network sink is better served by fewer, larger writes:
+//
+//	conn, _ := net.Dial("tcp", "logs.internal:5170")
+//	bw := asynclog.NewBatchWriter(conn, 256*1024, 5*time.Second)
+//	asynclog.SetOutput(bw)
+//	asynclog.Start()
+//	defer bw.Close()
+//	defer asynclog.Stop()
+//
+// The workers only write whole lines, so every batch is a sequence of newline delimited messages.
+// Batches are not split, a single write larger than size is passed on as is.
+//
+// Flush() and Stop() of the logger do not reach the batch, call Flush() or Close() on the
+// BatchWriter after them. Errors of interval writes are returned by the next call.
+func NewBatchWriter(w io.Writer, size int, interval time.Duration) *BatchWriter {
+	return &BatchWriter{w: w, size: size, interval: interval}
+}
+
+func (bw *BatchWriter) Write(p []byte) (int, error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	bw.buf = append(bw.buf, p...)
+	if len(bw.buf) >= bw.size {
+		return len(p), bw.flush()
+	}
+	if bw.timer == nil && bw.interval > 0 {
+		bw.timer = time.AfterFunc(bw.interval, func() {
+			bw.mu.Lock()
+			defer bw.mu.Unlock()
+			bw.timer = nil
+			if err := bw.flush(); err != nil {
+				bw.err = err
+			}
+		})
+	}
+	return len(p), bw.takeErr()
+}
+
+// Flush writes the accumulated batch to the underlying writer.
+func (bw *BatchWriter) Flush() error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if err := bw.flush(); err != nil {
+		return err
+	}
+	return bw.takeErr()
+}
+
+// Close writes the accumulated batch and stops the interval timer.
+// The underlying writer is not closed.
+func (bw *BatchWriter) Close() error {
+	return bw.Flush()
+}
+
+// flush writes buf to w, callers hold mu
+func (bw *BatchWriter) flush() error {
+	if bw.timer != nil {
+		bw.timer.Stop()
+		bw.timer = nil
+	}
+	if len(bw.buf) == 0 {
+		return nil
+	}
+	_, err := bw.w.Write(bw.buf)
+	bw.buf = bw.buf[:0]
+	return err
+}
+
+// takeErr returns and clears the error of the last interval write, callers hold mu
+func (bw *BatchWriter) takeErr() error {
+	err := bw.err
+	bw.err = nil
+	return err
+}
@@ -41,6 +41,39 @@ func BenchmarkLoggerDebug(b *testing.B) {
 	b.StopTimer()
 }
 
+func BenchmarkLoggerPrintArgs(b *testing.B) {
+	asynclog.SetOutput(io.Discard)
+	defer asynclog.SetOutput(os.Stdout)
+	asynclog.Start()
+	defer asynclog.Stop()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		asynclog.PrintArgs("Processing item ", i, " worker ", 1.5, " done ", true)
+	}
+	b.StopTimer()
+}
+
+func BenchmarkLoggerLogFields(b *testing.B) {
+	asynclog.SetOutput(io.Discard)
+	defer asynclog.SetOutput(os.Stdout)
+	asynclog.Start()
+	defer asynclog.Stop()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		asynclog.Log(asynclog.InfoLevel, "Processing item",
+			asynclog.Int("item", i),
+			asynclog.String("worker", "w1"),
+			asynclog.Float64("ratio", 1.5),
+			asynclog.Bool("done", true),
+		)
+	}
+	b.StopTimer()
+}
+
 const (
 	asynclogWorkers  = 15
 	asynclogBuffer   = 500
@@ -201,6 +234,39 @@ func BenchmarkConcurrentPrint(b *testing.B) {
 		wg.Wait()
 	}
 }
+
+func BenchmarkConcurrentPrintSharded(b *testing.B) {
+	asynclog.SetBuffer(asynclogBuffer)
+	asynclog.SetWorkers(asynclogWorkers)
+	asynclog.SetSharding(asynclogWorkers)
+	defer asynclog.SetSharding(1)
+	asynclog.Start()
+	defer asynclog.Stop()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+
+		for w := 0; w < benchmarkWorkers; w++ {
+			wg.Add(1)
+			go func(workerID int) {
+				defer wg.Done()
+
+				// Simulate CPU work
+				matrix := make([][]struct{}, i)
+				for x := range matrix {
+					for range matrix[x] {
+						time.Sleep(time.Nanosecond)
+
+						asynclog.Print("Processing item " + strconv.Itoa(i) + " worker " + strconv.Itoa(workerID))
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+}
 func BenchmarkConcurrentPrintArgs(b *testing.B) {
 	asynclog.SetBuffer(asynclogBuffer)
 	asynclog.SetWorkers(asynclogWorkers)
@@ -0,0 +1,83 @@
+package asynclog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+)
+
+var binaryFormat = false
+
+// Sets whether messages are written as length prefixed binary records instead of lines. Default is false.
+//
+// Each message is written as its length in bytes as a uvarint, see encoding/binary, followed by
+// the message, timestamp included. There is no line terminator, SetAppendNewline() and
+// SetLineTerminator() are ignored. Messages may contain newlines without breaking the framing.
+//
+// Meant for high volume archival read by tools rather than humans, use DecodeBinaryLog() to read
+// the records back. Only use it with outputs storing bytes as is, such as files.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetBinaryFormat(b bool) {
+	if std.isStarted {
+		return
+	}
+	binaryFormat = b
+}
+
+// appendFrame appends msg to dst as a binary record, see SetBinaryFormat()
+func appendFrame(dst []byte, msg message) []byte {
+	start := len(dst)
+	if timestamps {
+		dst = msg.time.AppendFormat(dst, timeFormat)
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, msg.text...)
+	dst = truncateLine(dst, start)
+
+	var hdr [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(hdr[:], uint64(len(dst)-start))
+	return slices.Insert(dst, start, hdr[:n]...)
+}
+
+// ErrCorruptBinaryLog is returned by DecodeBinaryLog() when a record is truncated or its length is invalid.
+var ErrCorruptBinaryLog = errors.New("corrupt binary log")
+
+// DecodeBinaryLog reads every record written with SetBinaryFormat(true) from r.
+//
+//	f, _ := os.Open("archive.bin")
+//	msgs, err := asynclog.DecodeBinaryLog(f)
+//
+// The messages read before an error are returned with it. A truncated last record, as left by
+// a crash, or a record longer than 256MB returns an error wrapping ErrCorruptBinaryLog.
+func DecodeBinaryLog(r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+	var msgs []string
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return msgs, nil
+		}
+		if err != nil {
+			return msgs, errors.Join(ErrCorruptBinaryLog, err)
+		}
+		if n > maxBinaryRecord {
+			return msgs, ErrCorruptBinaryLog
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return msgs, errors.Join(ErrCorruptBinaryLog, err)
+		}
+		msgs = append(msgs, string(buf))
+	}
+}
+
+// maxBinaryRecord is the largest record length DecodeBinaryLog() accepts, so a corrupt
+// length does not allocate an absurd amount of memory
+const maxBinaryRecord = 256 << 20
@@ -0,0 +1,102 @@
+package asynclog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// now is the clock of every time read by the logger, see SetClock()
+var now = time.Now
+
+// Sets the function used to read the current time, so tests can assert exact timestamps.
+// A nil fn restores time.Now. Default is time.Now.
+//
+//	fake := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+//	asynclog.SetClock(func() time.Time { return fake })
+//
+// It is used for SetTimestamps(), SetDebugElapsed(), SetLatencyTracking() and to refill the tokens of
+// SetGlobalRateLimit(), so a test can advance fn to let throttled messages through. With a clock that
+// does not advance, tokens are never refilled and Block delays each throttled message more than the last.
+// The output set by SetOutput() is tried again once fn has advanced 5 seconds, see SetFailoverWriter().
+// The workers still write their batches on a real timer, a fake clock does not change how often the output is flushed,
+// and the windows of PrintCoalesced() end on a real timer too.
+//
+// fn is called from every goroutine that logs and must be safe for concurrent use.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetClock(fn func() time.Time) {
+	if std.isStarted {
+		return
+	}
+	if fn == nil {
+		fn = time.Now
+	}
+	setNow(fn)
+}
+
+// setNow replaces the clock, stopping the ticker of a coarse clock it replaces
+func setNow(fn func() time.Time) {
+	stopCoarse()
+	stopCoarse = func() {}
+	now = fn
+}
+
+// coarse is the cached clock installed by SetCoarseClock()
+type coarse struct {
+	t    atomic.Pointer[time.Time]
+	stop chan struct{}
+}
+
+// stopCoarse stops the ticker of the running coarse clock, if any
+var stopCoarse = func() {}
+
+// Sets a cached clock updated every resolution by a background ticker, instead of calling
+// time.Now on every message. A resolution of 0 or less restores time.Now. Default is time.Now.
+//
+//	asynclog.SetTimestamps(true)
+//	asynclog.SetCoarseClock(time.Millisecond)
+//
+// Reading the cached time is a single atomic load, which pays off with timestamps on at high volume.
+// Every time read by the logger is off by up to resolution, including SetDebugElapsed()
+// and SetLatencyTracking(), so keep it well below the precision you need.
+//
+// Replaces a clock set by SetClock(). The ticker runs until the clock is replaced or Reset() is called.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetCoarseClock(resolution time.Duration) {
+	if std.isStarted {
+		return
+	}
+	if resolution <= 0 {
+		setNow(time.Now)
+		return
+	}
+
+	c := &coarse{stop: make(chan struct{})}
+	t := time.Now()
+	c.t.Store(&t)
+	setNow(func() time.Time { return *c.t.Load() })
+	go c.run(resolution)
+	stopCoarse = func() { close(c.stop) }
+}
+
+func (c *coarse) run(resolution time.Duration) {
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case t := <-ticker.C:
+			c.t.Store(&t)
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package asynclog
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// coalescer counts the PrintCoalesced() messages of a Logger per key until their window ends
+type coalescer struct {
+	mu      sync.Mutex
+	pending map[string]*coalesced
+}
+
+type coalesced struct {
+	msg   string // the last message sent with the key
+	n     int
+	timer *time.Timer
+}
+
+// PrintCoalesced collapses the messages sharing key within window into one, sent at the end of
+// the window with the number of messages, so a burst does not flood the output:
+//
+//	for _, host := range hosts {
+//		asynclog.PrintCoalesced("retry "+host, "retrying host "+host, time.Second)
+//	}
+//	// Output, once per host and second: retrying host db-1 (x42)
+//
+// The window starts with the first message of a key, the last message of the window is the one
+// written. A single message is written without a count. Pending messages are written by Stop().
+// The window runs on a real timer, it is not affected by SetClock().
+func PrintCoalesced(key, msg string, window time.Duration) {
+	std.PrintCoalesced(key, msg, window)
+}
+
+// PrintCoalesced collapses the messages sharing key within window into one. See PrintCoalesced().
+func (l *Logger) PrintCoalesced(key, msg string, window time.Duration) {
+	if !l.accepting() {
+		return
+	}
+	c := &l.coalesce
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.pending[key]; ok {
+		p.msg = msg
+		p.n++
+		return
+	}
+	if c.pending == nil {
+		c.pending = map[string]*coalesced{}
+	}
+	p := &coalesced{msg: msg, n: 1}
+	p.timer = time.AfterFunc(window, func() {
+		// Sent under the lock so Stop() waits for it, see flushCoalesced()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.pending[key] != p {
+			return // already written by Stop()
+		}
+		delete(c.pending, key)
+		l.sendCoalesced(p)
+	})
+	c.pending[key] = p
+}
+
+// flushCoalesced writes every pending PrintCoalesced() message, called by Stop()
+func (l *Logger) flushCoalesced() {
+	c := &l.coalesce
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.pending {
+		p.timer.Stop()
+		l.sendCoalesced(p)
+	}
+	c.pending = nil
+}
+
+func (l *Logger) sendCoalesced(p *coalesced) {
+	if !l.accepting() {
+		return
+	}
+	if p.n == 1 {
+		l.send(p.msg)
+		return
+	}
+	l.send(p.msg + " (x" + strconv.Itoa(p.n) + ")")
+}
@@ -0,0 +1,90 @@
+package asynclog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Configuration is a snapshot of the effective configuration of a logger, see Config().
+type Configuration struct {
+	Started        bool
+	Workers        int // the number of running workers once started, see SetWorkersAuto()
+	AutoWorkers    bool
+	Buffer         int
+	Level          Level
+	Output         string // "stdout", "stderr", "discard", the name of a file or the type of the writer
+	DropPolicy     DropPolicy
+	MaxQueuedBytes int
+	Deterministic  bool
+	Timestamps     bool
+	TimeFormat     string
+	Newline        bool
+	BinaryFormat   bool
+	CompactLevel   bool
+}
+
+// String returns the main settings on one line, e.g. "15 workers, buffer 100, level INFO, output stdout".
+func (c Configuration) String() string {
+	workers := strconv.Itoa(c.Workers)
+	if c.AutoWorkers && !c.Started {
+		workers = "auto"
+	}
+	return fmt.Sprintf("%s workers, buffer %d, level %s, output %s", workers, c.Buffer, c.Level, c.Output)
+}
+
+// Config returns the effective configuration of the logger, to check that flags and environment
+// variables were wired as expected:
+//
+//	asynclog.ConfigFromEnv()
+//	asynclog.Start()
+//	asynclog.Info("starting with " + asynclog.Config().String())
+//	// Output: [INFO] starting with 15 workers, buffer 100, level INFO, output stdout
+//
+// The returned struct is a copy, changing it does not configure anything.
+func Config() Configuration {
+	return std.Config()
+}
+
+// Config returns the effective configuration of the logger. See Config().
+//
+// Settings shared by every logger, like timestamps, are the global ones.
+func (l *Logger) Config() Configuration {
+	c := Configuration{
+		Started:        l.isStarted,
+		Workers:        l.workers,
+		AutoWorkers:    l.autoWorkers,
+		Buffer:         l.buffer,
+		Level:          l.GetLevel(),
+		Output:         describeWriter(l.out.current()),
+		DropPolicy:     l.dropPolicy,
+		MaxQueuedBytes: l.queue.max,
+		Deterministic:  l.determ,
+		Timestamps:     timestamps,
+		TimeFormat:     timeFormat,
+		Newline:        newline,
+		BinaryFormat:   binaryFormat,
+		CompactLevel:   compactLevel,
+	}
+	if l.isStarted {
+		c.Workers = len(l.pool)
+	}
+	return c
+}
+
+// describeWriter returns a short description of w for Configuration.Output
+func describeWriter(w io.Writer) string {
+	switch w {
+	case os.Stdout:
+		return "stdout"
+	case os.Stderr:
+		return "stderr"
+	case io.Discard:
+		return "discard"
+	}
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,134 @@
+package asynclog
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type contextField struct {
+	key   any
+	label string
+}
+
+var (
+	contextFieldsMu sync.RWMutex
+	contextFields   []contextField
+)
+
+// RegisterContextField registers a context key whose value is written as label=value by PrintContext().
+//
+// Register keys once during initialization, for example a request ID set by a middleware:
+//
+//	asynclog.RegisterContextField(requestIDKey{}, "reqid")
+//
+//	// in a handler
+//	asynclog.PrintContext(r.Context(), "handling request") // Output: handling request reqid=4f2a
+//
+// Fields are written in the order they are registered. Safe for concurrent use.
+func RegisterContextField(key any, label string) {
+	contextFieldsMu.Lock()
+	defer contextFieldsMu.Unlock()
+	contextFields = append(contextFields, contextField{key: key, label: label})
+}
+
+// PrintContext sends msg to the logger followed by the registered context fields found in ctx.
+//
+// Registered keys that are not set in ctx are skipped.
+func PrintContext(ctx context.Context, msg string) {
+	std.PrintContext(ctx, msg)
+}
+
+// PrintContext sends msg to the logger followed by the registered context fields found in ctx. See PrintContext().
+func (l *Logger) PrintContext(ctx context.Context, msg string) {
+	if !l.accepting() {
+		return
+	}
+	l.send(withContextFields(ctx, msg))
+}
+
+// withContextFields appends " label=value" to msg for every registered key set in ctx
+func withContextFields(ctx context.Context, msg string) string {
+	contextFieldsMu.RLock()
+	defer contextFieldsMu.RUnlock()
+
+	if len(contextFields) == 0 {
+		return msg
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for _, f := range contextFields {
+		v := ctx.Value(f.key)
+		if v == nil {
+			continue
+		}
+		writeField(&sb, f.label, v)
+	}
+	return sb.String()
+}
+
+// appendContextFields appends a Field to fields for every registered key set in ctx
+func appendContextFields(ctx context.Context, fields []Field) []Field {
+	contextFieldsMu.RLock()
+	defer contextFieldsMu.RUnlock()
+
+	for _, f := range contextFields {
+		if v := ctx.Value(f.key); v != nil {
+			fields = append(fields, anyField(f.label, v))
+		}
+	}
+	return fields
+}
+
+// With returns a Logger that appends the alternating keys and values of kv to every message, in logfmt:
+//
+//	reqLog := asynclog.With("reqid", id, "user", user)
+//	reqLog.Info("handling request") // Output: [INFO] handling request reqid=4f2a user=bob
+//
+// The derived Logger shares the channel, workers and configuration of the default logger,
+// it does not start anything on its own. Its fields are rendered once, so deriving is cheap
+// and logging through it costs one concatenation. A key without a value is written with an empty value.
+// On a message of several lines, like one with a stack trace, the fields end the first line.
+func With(kv ...any) *Logger {
+	return std.With(kv...)
+}
+
+// WithContext returns a Logger that appends the registered context fields found in ctx to
+// every message, see RegisterContextField(). Like With(), it shares the default logger.
+//
+//	log := asynclog.WithContext(r.Context())
+//	log.Info("handling request") // Output: [INFO] handling request reqid=4f2a
+func WithContext(ctx context.Context) *Logger {
+	return std.WithContext(ctx)
+}
+
+// With returns a Logger that appends the keys and values of kv to every message. See With().
+//
+// The derived Logger shares the channel, workers and configuration of l, and keeps the fields of l.
+// Configuring or stopping it configures or stops l.
+func (l *Logger) With(kv ...any) *Logger {
+	var sb strings.Builder
+	sb.WriteString(l.fields)
+	recFields := slices.Clip(l.recFields)
+	for i := 0; i < len(kv); i += 2 {
+		var val any = ""
+		if i+1 < len(kv) {
+			val = kv[i+1]
+		}
+		key := toString(kv[i])
+		writeField(&sb, key, val)
+		recFields = append(recFields, anyField(key, val))
+	}
+	return &Logger{core: l.core, fields: sb.String(), recFields: recFields}
+}
+
+// WithContext returns a Logger that appends the registered context fields found in ctx to every message. See WithContext().
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	return &Logger{
+		core:      l.core,
+		fields:    withContextFields(ctx, l.fields),
+		recFields: appendContextFields(ctx, slices.Clip(l.recFields)),
+	}
+}
@@ -0,0 +1,130 @@
+package asynclog
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dbWriter inserts every line written to it as a row of a database table
+type dbWriter struct {
+	db     *sql.DB
+	insert string
+}
+
+// NewDBWriter returns a writer inserting every line into table, for logs searchable with SQL.
+//
+// The table needs the columns time, level, file, line and message, for SQLite:
+//
+//	CREATE TABLE logs (time TIMESTAMP, level TEXT, file TEXT, line INTEGER, message TEXT)
+//
+// Then:
+//
+//	asynclog.SetOutput(asynclog.NewDBWriter(db, "logs"))
+//
+// Each batch written by a worker is inserted in a single transaction. Lines are split into
+// their parts as written by the logger: the timestamp of SetTimestamps(), the level tag and the
+// "file.go:line" of Debug(). Parts a line does not have are empty, or the insert time for the timestamp.
+// The prefixes of SetSequenceNumbers() and SetIncludeHostPID() are left out of the row.
+// Messages spanning several lines, such as Stack(), become one row per line.
+//
+// The statement uses ? placeholders, as SQLite and MySQL do. table is not escaped and must be trusted.
+func NewDBWriter(db *sql.DB, table string) io.Writer {
+	return &dbWriter{
+		db:     db,
+		insert: "INSERT INTO " + table + " (time, level, file, line, message) VALUES (?, ?, ?, ?, ?)",
+	}
+}
+
+func (dw *dbWriter) Write(p []byte) (int, error) {
+	tx, err := dw.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(dw.insert)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte{'\n'}), []byte{'\n'}) {
+		r := parseRecord(string(line))
+		if _, err := stmt.Exec(r.time, r.level, r.file, r.line, r.message); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// record is a written line split into its parts, see NewDBWriter()
+type record struct {
+	time    time.Time
+	level   string
+	file    string
+	line    int
+	message string
+}
+
+// parseRecord splits a line as written by the workers into its parts
+func parseRecord(s string) record {
+	r := record{time: now()}
+
+	if timestamps {
+		if t, rest, ok := cutTimestamp(s); ok {
+			r.time = t
+			s = rest
+		}
+	}
+	if sequenceNumbers && strings.HasPrefix(s, "#") {
+		_, s, _ = strings.Cut(s, " ")
+	}
+	if includeHostPID {
+		s = strings.TrimPrefix(s, hostPID())
+	}
+	if lvl, ok := lineLevel([]byte(s)); ok {
+		r.level = lvl.String()
+		s = strings.TrimLeft(s[len(lvl.String())+2:], " ")
+	}
+	if loc, rest, ok := strings.Cut(s, " "); ok {
+		if file, line, ok := strings.Cut(loc, ".go:"); ok {
+			if n, err := strconv.Atoi(line); err == nil {
+				r.file = file + ".go"
+				r.line = n
+				s = rest
+			}
+		}
+	}
+	r.message = s
+	return r
+}
+
+// cutTimestamp parses the timestamp at the start of s and returns the rest of s after it.
+//
+// A timestamp has as many spaces as the layout, or more with a padded field like "_2",
+// so s is cut at each of these spaces in turn until the prefix parses.
+func cutTimestamp(s string) (t time.Time, rest string, ok bool) {
+	spaces := strings.Count(timeFormat, " ")
+	end := 0
+	for i := 0; i <= spaces+1; i++ {
+		next := strings.IndexByte(s[end:], ' ')
+		if next < 0 {
+			return time.Time{}, s, false
+		}
+		end += next
+		if i >= spaces {
+			if t, err := time.ParseInLocation(timeFormat, s[:end], time.Local); err == nil {
+				return t, s[end+1:], true
+			}
+		}
+		end++
+	}
+	return time.Time{}, s, false
+}
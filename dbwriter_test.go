@@ -0,0 +1,58 @@
+package asynclog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecord(t *testing.T) {
+	t.Cleanup(Reset)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.Local)
+
+	tests := []struct {
+		name      string
+		layout    string
+		seq, host bool
+		line      string
+		time      time.Time
+		level     string
+		file      string
+		lineNo    int
+		message   string
+	}{
+		{name: "plain", line: "hello world", message: "hello world"},
+		{name: "level", line: "[WARN] disk full", level: "WARN", message: "disk full"},
+		{name: "debug", line: "[DEBUG] main.go:12 started", level: "DEBUG", file: "main.go", lineNo: 12, message: "started"},
+		{
+			name: "default layout", layout: defaultTimeFormat,
+			line: at.Format(defaultTimeFormat) + " [INFO] up", time: at, level: "INFO", message: "up",
+		},
+		{
+			name: "variable width layout", layout: time.RFC3339Nano,
+			line: at.Format(time.RFC3339Nano) + " [INFO] up", time: at, level: "INFO", message: "up",
+		},
+		{
+			name: "padded layout", layout: time.Stamp,
+			line: "Jan  2 03:04:05 [ERROR] down", time: time.Date(0, 1, 2, 3, 4, 5, 0, time.Local), level: "ERROR", message: "down",
+		},
+		{
+			name: "sequence and host", layout: defaultTimeFormat, seq: true, host: true,
+			line: at.Format(defaultTimeFormat) + " #000042 " + hostPID() + "[WARN] slow", time: at, level: "WARN", message: "slow",
+		},
+		{name: "sequence without level", seq: true, line: "#000001 plain", message: "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamps, timeFormat = tt.layout != "", tt.layout
+			sequenceNumbers, includeHostPID = tt.seq, tt.host
+
+			r := parseRecord(tt.line)
+			if !tt.time.IsZero() && !r.time.Equal(tt.time) {
+				t.Errorf("time = %v, want %v", r.time, tt.time)
+			}
+			if r.level != tt.level || r.file != tt.file || r.line != tt.lineNo || r.message != tt.message {
+				t.Errorf("parseRecord(%q) = %+v", tt.line, r)
+			}
+		})
+	}
+}
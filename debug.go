@@ -0,0 +1,33 @@
+//go:build !asynclog_nodebug
+
+package asynclog
+
+// Sends a string to the logger prepended with the file and line number of the caller.
+//
+// If the logger is not started, the message is ignored.
+//
+// FIXME: add % increase from the benchmarks
+// Tip: fmt.Sprintf() _% slower than a basic string concatenation.
+//
+// Debug messages are filtered at DebugLevel, see SetLevel(). Build with -tags asynclog_nodebug
+// to compile Debug(), DebugHere() and Logger.Debug() to empty functions the compiler inlines away.
+//
+// Thread safe!
+func Debug(msg string) {
+	std.debug(DebugLevel, 1, msg)
+}
+
+// DebugHere() is a convenience function that calls Debug() with whatever is set to SetHere() default "Here".
+//
+// Filtered at the level set by SetHereLevel().
+func DebugHere() {
+	if !std.enabled(hereLevel) {
+		return
+	}
+	std.debug(hereLevel, 1, hereMsg())
+}
+
+// Sends a string to the logger prepended with the file and line number of the caller. See Debug().
+func (l *Logger) Debug(msg string) {
+	l.debug(DebugLevel, 1, msg)
+}
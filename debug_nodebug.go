@@ -0,0 +1,12 @@
+//go:build asynclog_nodebug
+
+package asynclog
+
+// Debug does nothing, the program is built with the asynclog_nodebug tag.
+func Debug(msg string) {}
+
+// DebugHere does nothing, the program is built with the asynclog_nodebug tag.
+func DebugHere() {}
+
+// Debug does nothing, the program is built with the asynclog_nodebug tag.
+func (l *Logger) Debug(msg string) {}
@@ -0,0 +1,61 @@
+//go:build !asynclog_nodebug
+
+package asynclog_test
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	asynclog "github.com/ninesl/asynclog-go"
+)
+
+func TestDebugSameLineConcurrent(t *testing.T) {
+	const goroutines = 50
+
+	var buf bytes.Buffer
+	asynclog.SetOutput(&buf)
+	defer asynclog.SetOutput(os.Stdout)
+
+	asynclog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			asynclog.Debug("goroutine " + strconv.Itoa(g)) // every goroutine shares the cached DebugInfo of this line
+		}(g)
+	}
+	wg.Wait()
+	asynclog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != goroutines {
+		t.Fatalf("got %d lines, want %d", len(lines), goroutines)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "debug_test.go:") {
+			t.Fatalf("line %q does not start with the caller", line)
+		}
+	}
+}
+
+func TestDebugRecord(t *testing.T) {
+	var rc recordCollector
+	l := asynclog.NewLogger()
+	l.SetRecordWriter(&rc)
+
+	l.Start()
+	l.Debug("checkpoint")
+	l.Stop()
+
+	if len(rc.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(rc.records))
+	}
+	if r := rc.records[0]; r.Level != asynclog.DebugLevel || r.File != "debug_test.go" || r.Line == 0 || r.Msg != "checkpoint" {
+		t.Errorf("Debug record = %+v", r)
+	}
+}
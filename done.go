@@ -0,0 +1,40 @@
+package asynclog
+
+// closed is returned by PrintWithDone() for messages that are not sent
+var closed = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// PrintWithDone sends msg to the logger like Print() and returns a channel closed once a worker
+// has written and flushed it to the output, to wait for one important line without a global Flush():
+//
+//	<-asynclog.PrintWithDone("payment 8812 captured")
+//	ackPayment(8812)
+//
+// The channel is also closed if the message is dropped, by the drop policy, a middleware or
+// SetGlobalRateLimit(), so waiting on it never hangs. It is returned already closed if the logger
+// does not accept messages. A write error does not keep it open, see SetErrorHandler().
+//
+// Messages sent with PrintWithDone() are never spilled by SetOverflowSpill().
+func PrintWithDone(msg string) <-chan struct{} {
+	return std.PrintWithDone(msg)
+}
+
+// PrintWithDone sends msg to the logger and returns a channel closed once it is written. See PrintWithDone().
+func (l *Logger) PrintWithDone(msg string) <-chan struct{} {
+	if !l.accepting() {
+		return closed
+	}
+	done := make(chan struct{})
+	l.enqueue(message{text: msg, done: done})
+	return done
+}
+
+// closeDone closes the done channel of m, if it has one
+func closeDone(m message) {
+	if m.done != nil {
+		close(m.done)
+	}
+}
@@ -0,0 +1,118 @@
+package asynclog
+
+// DropPolicy decides what happens when a message is sent while the buffer is full.
+type DropPolicy int
+
+const (
+	// Block waits for the workers to make room, no message is lost. This is the default.
+	Block DropPolicy = iota
+	// DropNewest discards the message being sent and keeps the queued ones.
+	DropNewest
+	// DropOldest discards the oldest queued message to make room for the one being sent,
+	// keeping the freshest context when the logger is overloaded.
+	DropOldest
+)
+
+// SetDropPolicy sets what happens when a message is sent while the buffer is full. Default is Block.
+//
+// With DropNewest or DropOldest sending never waits on the workers, at the cost of losing
+// messages under load. Dropped() counts them. Once SetMaxQueuedBytes() is reached, the message
+// being sent is dropped with either policy, queued messages are not discarded to make room.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetDropPolicy(p DropPolicy) {
+	std.SetDropPolicy(p)
+}
+
+// SetDropPolicy sets what happens when a message is sent while the buffer is full. See SetDropPolicy().
+func (l *Logger) SetDropPolicy(p DropPolicy) {
+	if l.isStarted {
+		return
+	}
+	l.dropPolicy = p
+}
+
+// Dropped returns the number of messages discarded because of the drop policy,
+// or because the channel set by SetOutputChannel() was full, or the lines dropped
+// from the buffer set by SetFailoverBuffer().
+func Dropped() uint64 {
+	return std.Dropped()
+}
+
+// Dropped returns the number of messages discarded because of the drop policy. See Dropped().
+func (l *Logger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// push sends m to a channel according to the drop policy.
+// Urgent messages go to their own channel, see sendLevel().
+func (l *Logger) push(m message) {
+	l.pushTo(l.pick(), m)
+}
+
+// pushTo is push for a message sent to ch rather than a channel picked at random
+func (l *Logger) pushTo(ch chan message, m message) {
+	if m.urgent {
+		ch = l.urgent
+	}
+
+	switch l.dropPolicy {
+	case DropNewest:
+		select {
+		case ch <- m:
+		default:
+			l.drop(m)
+		}
+
+	case DropOldest:
+		select {
+		case ch <- m:
+			return
+		default:
+		}
+		// Another producer or a worker may take the freed slot first,
+		// in which case the new message is dropped instead.
+		select {
+		case old := <-ch:
+			l.drop(old)
+		default:
+		}
+		select {
+		case ch <- m:
+		default:
+			l.drop(m)
+		}
+
+	default:
+		if l.trySpill(m) {
+			return
+		}
+		ch <- m
+	}
+}
+
+// acquire reserves the bytes of m under SetMaxQueuedBytes(), waiting for room with the Block policy.
+// With the other policies m is dropped if it does not fit, and false is returned.
+func (l *Logger) acquire(m message) bool {
+	if l.dropPolicy == Block {
+		l.queue.acquire(len(m.text))
+		return true
+	}
+	if !l.queue.tryAcquire(len(m.text)) {
+		l.dropped.Add(1)
+		closeDone(m)
+		return false
+	}
+	return true
+}
+
+// drop discards m, a message that will never reach the workers
+func (l *Logger) drop(m message) {
+	l.dropped.Add(1)
+	l.queue.release(len(m.text))
+	closeDone(m)
+}
@@ -0,0 +1,112 @@
+package asynclog
+
+import (
+	"strings"
+	"sync"
+)
+
+// Entry is a chainable builder for a single message.
+//
+//	asynclog.New().Level(asynclog.WarnLevel).Field("user", id).Msg("login failed")
+//	// Output: [WARN] login failed user=42
+//
+// Every method returns the same *Entry. Msg() sends the message and returns the entry
+// to an internal pool, so an Entry must not be used after calling Msg().
+type Entry struct {
+	level   Level
+	leveled bool
+	fields  []entryField
+}
+
+type entryField struct {
+	key string
+	val any
+}
+
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return &Entry{}
+	},
+}
+
+// New returns an empty *Entry from the pool.
+//
+// Without a call to Level() the message is sent like Print(), without a level tag.
+func New() *Entry {
+	return entryPool.Get().(*Entry)
+}
+
+// Level sets the level of the entry. The message is tagged and filtered like Info(), Warn(), etc.
+func (e *Entry) Level(l Level) *Entry {
+	e.level = l
+	e.leveled = true
+	return e
+}
+
+// Field adds a key=value pair that is written after the message.
+// The value is quoted in logfmt style when it contains spaces, quotes or '='.
+//
+// Fields are written in the order they are added.
+func (e *Entry) Field(key string, val any) *Entry {
+	e.fields = append(e.fields, entryField{key: key, val: val})
+	return e
+}
+
+// Msg sends the entry to the logger with msg as the message and releases the entry.
+//
+// If the logger is not started or the level is filtered, nothing is formatted.
+func (e *Entry) Msg(msg string) {
+	defer e.release()
+
+	if e.leveled {
+		if !std.enabled(e.level) {
+			return
+		}
+	} else if !std.accepting() {
+		return
+	}
+
+	sb := builderPool.Get().(*strings.Builder)
+	defer func() {
+		sb.Reset()
+		builderPool.Put(sb)
+	}()
+
+	if e.leveled {
+		sb.WriteString(e.level.tag())
+	}
+	sb.WriteString(msg)
+	for _, f := range e.fields {
+		writeField(sb, f.key, f.val)
+	}
+
+	rec := e.record(msg)
+	if e.leveled {
+		std.sendRecord(e.level, sb.String(), rec)
+		return
+	}
+	std.enqueue(message{text: sb.String(), rec: rec})
+}
+
+// record returns the record of the entry with its fields, or nil if the logger has no RecordWriter
+func (e *Entry) record(msg string) *Record {
+	if std.records.w == nil {
+		return nil
+	}
+	rec := &Record{Level: InfoLevel, Msg: msg, Fields: make([]Field, 0, len(e.fields))}
+	if e.leveled {
+		rec.Level = e.level
+	}
+	for _, f := range e.fields {
+		rec.Fields = append(rec.Fields, anyField(f.key, f.val))
+	}
+	return rec
+}
+
+func (e *Entry) release() {
+	clear(e.fields) // don't hold on to field values while pooled
+	e.fields = e.fields[:0]
+	e.level = DebugLevel
+	e.leveled = false
+	entryPool.Put(e)
+}
@@ -0,0 +1,116 @@
+package asynclog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ConfigFromEnv configures the logger from environment variables, so it can be tuned per
+// environment without recompiling:
+//
+//	ASYNCLOG_WORKERS     number of workers, or "auto", see SetWorkers() and SetWorkersAuto()
+//	ASYNCLOG_BUFFER      buffer size, see SetBuffer()
+//	ASYNCLOG_LEVEL       trace, debug, info, warn, error or fatal, see SetLevel()
+//	ASYNCLOG_OUTPUT      stdout, stderr, discard or the path of a file to append to, see SetOutput()
+//	ASYNCLOG_TIMESTAMPS  true or false, see SetTimestamps()
+//
+// Unset variables leave the configuration as it is. Invalid values are skipped and reported
+// in the returned error, the valid ones are still applied:
+//
+//	if err := asynclog.ConfigFromEnv(); err != nil {
+//		fmt.Fprintln(os.Stderr, err)
+//	}
+//	asynclog.Start()
+//
+// A file opened for ASYNCLOG_OUTPUT stays open for the lifetime of the program.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func ConfigFromEnv() error {
+	if std.isStarted {
+		return nil
+	}
+
+	var errs []error
+	env := func(key string, apply func(string) error) {
+		v, ok := os.LookupEnv(key)
+		if !ok || v == "" {
+			return
+		}
+		if err := apply(v); err != nil {
+			errs = append(errs, fmt.Errorf("asynclog: %s=%q: %w", key, v, err))
+		}
+	}
+
+	env("ASYNCLOG_WORKERS", func(v string) error {
+		if v == "auto" {
+			SetWorkersAuto()
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return errors.New("not a positive number or auto")
+		}
+		SetWorkers(n)
+		return nil
+	})
+	env("ASYNCLOG_BUFFER", func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return errors.New("not a non-negative number")
+		}
+		SetBuffer(n)
+		return nil
+	})
+	env("ASYNCLOG_LEVEL", func(v string) error {
+		lvl, ok := parseLevel(v)
+		if !ok {
+			return errors.New("unknown level")
+		}
+		SetLevel(lvl)
+		return nil
+	})
+	env("ASYNCLOG_OUTPUT", func(v string) error {
+		switch v {
+		case "stdout":
+			SetOutput(os.Stdout)
+		case "stderr":
+			SetOutput(os.Stderr)
+		case "discard":
+			SetOutput(nil)
+		default:
+			f, err := os.OpenFile(v, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return err
+			}
+			SetOutput(f)
+		}
+		return nil
+	})
+	env("ASYNCLOG_TIMESTAMPS", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("not a boolean")
+		}
+		SetTimestamps(b)
+		return nil
+	})
+
+	return errors.Join(errs...)
+}
+
+// parseLevel returns the level named s, case insensitive, e.g. "info" or "WARN"
+func parseLevel(s string) (Level, bool) {
+	for lvl := TraceLevel; lvl <= FatalLevel; lvl++ {
+		if strings.EqualFold(s, lvl.String()) {
+			return lvl, true
+		}
+	}
+	return 0, false
+}
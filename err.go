@@ -0,0 +1,117 @@
+package asynclog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errChain = false
+	errStack = true
+)
+
+// Sets whether Err() includes the unwrap chain of an error. Default is false.
+//
+// When enabled, the dynamic type of every error returned by errors.Unwrap() is written after the message:
+//
+//	asynclog.Err(err, "reading config")
+//	// Output: [ERROR] reading config: open app.yml: no such file (*fmt.wrapError -> *fs.PathError -> syscall.Errno)
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetErrChain(b bool) {
+	if std.isStarted {
+		return
+	}
+	errChain = b
+}
+
+// Sets whether ErrorWithStack() captures the stack trace of the calling goroutine. Default is true.
+//
+// Capturing a stack is expensive, turn it off on hot error paths to keep the error type only.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetErrStack(b bool) {
+	if std.isStarted {
+		return
+	}
+	errStack = b
+}
+
+// ErrorWithStack sends "msg: err.Error()" to the logger at ErrorLevel, followed by the
+// dynamic type of err and the stack trace of the calling goroutine:
+//
+//	asynclog.ErrorWithStack(err, "reading config")
+//	// Output:
+//	// [ERROR] reading config: open app.yml: no such file type=*fs.PathError
+//	//	main.loadConfig
+//	//		/app/config.go:31
+//	//	main.main
+//	//		/app/main.go:17
+//
+// Nothing is sent if err is nil. See SetErrStack() to leave out the stack trace.
+func ErrorWithStack(err error, msg string) {
+	if err == nil || !std.enabled(ErrorLevel) {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(ErrorLevel.tag())
+	sb.WriteString(msg)
+	sb.WriteString(": ")
+	sb.WriteString(err.Error())
+	sb.WriteString(" type=")
+	sb.WriteString(fmt.Sprintf("%T", err))
+	if !errStack {
+		std.sendLevel(ErrorLevel, sb.String())
+		return
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(stackTrace(1, defaultStackDepth))
+
+	// Already traced, not again by SetStackTraceLevel()
+	std.sendTraced(ErrorLevel, sb.String(), std.newRecord(ErrorLevel, sb.String()))
+}
+
+// Err sends "msg: err.Error()" to the logger at ErrorLevel.
+//
+// If err is nil nothing is sent, so it can replace the usual boilerplate:
+//
+//	if err != nil {
+//		asynclog.Error("reading config: " + err.Error())
+//	}
+//	// becomes
+//	asynclog.Err(err, "reading config")
+func Err(err error, msg string) {
+	if err == nil || !std.enabled(ErrorLevel) {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(ErrorLevel.tag())
+	sb.WriteString(msg)
+	sb.WriteString(": ")
+	sb.WriteString(err.Error())
+
+	if errChain {
+		if next := errors.Unwrap(err); next != nil {
+			sb.WriteString(" (")
+			sb.WriteString(fmt.Sprintf("%T", err))
+			for ; next != nil; next = errors.Unwrap(next) {
+				sb.WriteString(" -> ")
+				sb.WriteString(fmt.Sprintf("%T", next))
+			}
+			sb.WriteByte(')')
+		}
+	}
+
+	std.sendLevel(ErrorLevel, sb.String())
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package asynclog
+
+import (
+	"bytes"
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+)
+
+// Event types of ReportEventW
+const (
+	eventlogError       = 0x0001
+	eventlogWarning     = 0x0002
+	eventlogInformation = 0x0004
+)
+
+// eventLogWriter reports every line written to it as an event
+type eventLogWriter struct {
+	handle uintptr
+}
+
+// NewEventLogWriter returns a writer reporting every line to the Windows Event Log under source
+// as its own event. Only available on Windows.
+//
+//	ew, err := asynclog.NewEventLogWriter("myservice")
+//	if err != nil {
+//		return err
+//	}
+//	defer ew.Close()
+//	asynclog.SetOutput(ew)
+//
+// The event type is taken from the level tag: error for "[ERROR]" and "[FATAL]", warning
+// for "[WARN]" and information for everything else. Messages spanning several lines, such
+// as Stack(), become one event per line.
+//
+// The source should be registered beforehand, by an installer or with New-EventLog, otherwise
+// the Event Viewer shows a missing description warning next to every message.
+func NewEventLogWriter(source string) (io.WriteCloser, error) {
+	src, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(src)))
+	if h == 0 {
+		return nil, err
+	}
+	return &eventLogWriter{handle: h}, nil
+}
+
+func (ew *eventLogWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte{'\n'}), []byte{'\n'}) {
+		msg, err := syscall.UTF16PtrFromString(string(line))
+		if err != nil {
+			return 0, err
+		}
+		strs := []*uint16{msg}
+		ok, _, err := procReportEvent.Call(
+			ew.handle,
+			uintptr(eventLogType(line)),
+			0, // category
+			1, // event ID
+			0, // user SID
+			1, // number of strings
+			0, // raw data size
+			uintptr(unsafe.Pointer(&strs[0])),
+			0, // raw data
+		)
+		if ok == 0 {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (ew *eventLogWriter) Close() error {
+	if ok, _, err := procDeregisterEventSource.Call(ew.handle); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// eventLogType maps the level tag of line to an event type, information if it has none
+func eventLogType(line []byte) uint16 {
+	lvl, _ := lineLevel(line)
+	switch {
+	case lvl >= ErrorLevel:
+		return eventlogError
+	case lvl == WarnLevel:
+		return eventlogWarning
+	default:
+		return eventlogInformation
+	}
+}
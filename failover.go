@@ -0,0 +1,95 @@
+package asynclog
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+)
+
+// failover keeps the output that could not be written in memory until the output recovers.
+// It is protected by the lock of the lockedWriter holding it.
+type failover struct {
+	max     int // 0 disables the failover buffer
+	backlog []byte
+	dropped *atomic.Uint64 // dropped counter of the Logger
+}
+
+// write writes the backlog and p to w, keeping whatever fails in the backlog.
+//
+// Only the error that starts a backlog is returned, so it is reported once rather than for every batch.
+func (fo *failover) write(w io.Writer, p []byte) (int, error) {
+	if len(fo.backlog) > 0 {
+		if fo.replay(w) != nil {
+			fo.spill(p)
+			return len(p), nil
+		}
+	}
+	n, err := w.Write(p)
+	if err != nil {
+		fo.spill(p[n:])
+	}
+	return len(p), err
+}
+
+// replay writes as much of the backlog to w as it accepts
+func (fo *failover) replay(w io.Writer) error {
+	n, err := w.Write(fo.backlog)
+	fo.backlog = fo.backlog[:copy(fo.backlog, fo.backlog[n:])]
+	return err
+}
+
+// spill appends p to the backlog, dropping the oldest lines beyond max
+func (fo *failover) spill(p []byte) {
+	fo.backlog = append(fo.backlog, p...)
+	over := len(fo.backlog) - fo.max
+	if over <= 0 {
+		return
+	}
+	// Cut at a line boundary so no partial line is replayed
+	if i := bytes.IndexByte(fo.backlog[over:], '\n'); i >= 0 {
+		over += i + 1
+	} else {
+		over = len(fo.backlog)
+	}
+	fo.dropped.Add(uint64(bytes.Count(fo.backlog[:over], []byte{'\n'})))
+	fo.backlog = fo.backlog[:copy(fo.backlog, fo.backlog[over:])]
+}
+
+// retry writes the failover backlog to the output, if there is one
+func (lw *lockedWriter) retry() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if len(lw.fo.backlog) > 0 {
+		lw.fo.replay(lw.w)
+	}
+}
+
+// Sets the maximum bytes kept in memory while the output fails. Default is 0, disabled.
+//
+// When writing to the output fails, because the disk is full or the network is down, the
+// unwritten lines are kept in memory instead of being lost. Every following write and every
+// flush interval retries the output, and the backlog is written first once it recovers, so
+// the order of the lines is kept. Beyond maxBytes the oldest lines are dropped and counted by Dropped().
+//
+// The error that starts the backlog is reported to the error handler, the retries are silent.
+// Whatever is still in the backlog when the logger stops is lost.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetFailoverBuffer(maxBytes int) {
+	std.SetFailoverBuffer(maxBytes)
+}
+
+// Sets the maximum bytes kept in memory while the output fails. See SetFailoverBuffer().
+func (l *Logger) SetFailoverBuffer(maxBytes int) {
+	if l.isStarted {
+		return
+	}
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.fo.max = maxBytes
+	l.out.fo.dropped = &l.dropped
+}
@@ -0,0 +1,220 @@
+package asynclog
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fieldKind is the type of the value held by a Field
+type fieldKind uint8
+
+const (
+	stringField fieldKind = iota
+	intField
+	uintField
+	floatField
+	boolField
+	durationField
+	errField
+)
+
+// Field is a typed key=value pair for Log(), built by String(), Int() and the other constructors.
+//
+// Unlike the any values of Entry.Field() and PrintLogfmt(), a Field holds its value without
+// boxing it in an interface, so logging with Fields does not allocate per value.
+type Field struct {
+	key  string
+	kind fieldKind
+	str  string
+	num  int64 // int, uint, float bits, bool and duration values
+	err  error
+}
+
+// String returns a Field with a string value.
+func String(key, val string) Field {
+	return Field{key: key, kind: stringField, str: val}
+}
+
+// Int returns a Field with an int value.
+func Int(key string, val int) Field {
+	return Field{key: key, kind: intField, num: int64(val)}
+}
+
+// Int64 returns a Field with an int64 value.
+func Int64(key string, val int64) Field {
+	return Field{key: key, kind: intField, num: val}
+}
+
+// Uint64 returns a Field with a uint64 value.
+func Uint64(key string, val uint64) Field {
+	return Field{key: key, kind: uintField, num: int64(val)}
+}
+
+// Float64 returns a Field with a float64 value.
+func Float64(key string, val float64) Field {
+	return Field{key: key, kind: floatField, num: int64(math.Float64bits(val))}
+}
+
+// Bool returns a Field with a bool value.
+func Bool(key string, val bool) Field {
+	f := Field{key: key, kind: boolField}
+	if val {
+		f.num = 1
+	}
+	return f
+}
+
+// Duration returns a Field with a time.Duration value, written like "1.5s".
+func Duration(key string, val time.Duration) Field {
+	return Field{key: key, kind: durationField, num: int64(val)}
+}
+
+// ErrField returns a Field with the key "error" and err.Error() as value, or "<nil>" if err is nil.
+//
+// It is not named Err, which sends an error message, see Err().
+func ErrField(err error) Field {
+	return Field{key: "error", kind: errField, err: err}
+}
+
+// Key returns the key of the field.
+func (f Field) Key() string {
+	return f.key
+}
+
+// Value returns the value of the field, typed by its constructor: a string, int64, uint64,
+// float64, bool, time.Duration or error. Int() values are returned as int64.
+func (f Field) Value() any {
+	switch f.kind {
+	case intField:
+		return f.num
+	case uintField:
+		return uint64(f.num)
+	case floatField:
+		return math.Float64frombits(uint64(f.num))
+	case boolField:
+		return f.num == 1
+	case durationField:
+		return time.Duration(f.num)
+	case errField:
+		return f.err
+	default:
+		return f.str
+	}
+}
+
+// String returns the field as it is written to the output, key=value in logfmt.
+func (f Field) String() string {
+	return string(f.appendTo(nil)[1:])
+}
+
+// anyField returns the Field of a key and value given as any, like the fields of With() and Entry.Field()
+func anyField(key string, val any) Field {
+	switch v := val.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case uint64:
+		return Uint64(key, v)
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case error:
+		return Field{key: key, kind: errField, err: v}
+	default:
+		return String(key, toString(val))
+	}
+}
+
+// redactFields replaces the values of fields whose key is set by RedactKeys(), in place
+func redactFields(fields []Field) {
+	for i, f := range fields {
+		if redacted(f.key) {
+			fields[i] = String(f.key, redactedValue)
+		}
+	}
+}
+
+// appendTo appends " key=value" to dst in logfmt
+func (f Field) appendTo(dst []byte) []byte {
+	dst = append(dst, ' ')
+	dst = append(dst, f.key...)
+	dst = append(dst, '=')
+	if redacted(f.key) {
+		return append(dst, redactedValue...)
+	}
+	switch f.kind {
+	case intField:
+		return strconv.AppendInt(dst, f.num, 10)
+	case uintField:
+		return strconv.AppendUint(dst, uint64(f.num), 10)
+	case floatField:
+		return strconv.AppendFloat(dst, math.Float64frombits(uint64(f.num)), 'g', -1, 64)
+	case boolField:
+		return strconv.AppendBool(dst, f.num == 1)
+	case durationField:
+		return append(dst, time.Duration(f.num).String()...)
+	case errField:
+		if f.err == nil {
+			return append(dst, "<nil>"...)
+		}
+		return appendLogfmtValue(dst, f.err.Error())
+	default:
+		return appendLogfmtValue(dst, f.str)
+	}
+}
+
+// appendLogfmtValue appends s to dst, quoted if needed, see needsQuote()
+func appendLogfmtValue(dst []byte, s string) []byte {
+	if needsQuote(s) {
+		return strconv.AppendQuote(dst, s)
+	}
+	return append(dst, s...)
+}
+
+// fieldBufPool holds the buffers Log() renders messages into
+var fieldBufPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 256)
+		return &buf
+	},
+}
+
+// Log sends msg at level lvl followed by fields in logfmt, without any reflection or boxing:
+//
+//	asynclog.Log(asynclog.InfoLevel, "request done",
+//		asynclog.String("path", r.URL.Path),
+//		asynclog.Int("status", 200),
+//		asynclog.Duration("took", time.Since(start)),
+//	)
+//	// Output: [INFO] request done path=/users status=200 took=12.5ms
+//
+// The only allocation is the message itself. Nothing is rendered if lvl is filtered.
+func Log(lvl Level, msg string, fields ...Field) {
+	std.Log(lvl, msg, fields...)
+}
+
+// Log sends msg at level lvl followed by fields in logfmt. See Log().
+func (l *Logger) Log(lvl Level, msg string, fields ...Field) {
+	if !l.enabled(lvl) {
+		return
+	}
+
+	bp := fieldBufPool.Get().(*[]byte)
+	buf := lvl.appendTag((*bp)[:0])
+	buf = append(buf, msg...)
+	for _, f := range fields {
+		buf = f.appendTo(buf)
+	}
+	l.sendRecord(lvl, string(buf), l.newFieldsRecord(lvl, msg, fields))
+
+	*bp = buf
+	fieldBufPool.Put(bp)
+}
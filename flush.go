@@ -0,0 +1,99 @@
+package asynclog
+
+import (
+	"time"
+)
+
+// Flush blocks until every message sent before the call is written to the output,
+// then returns the first error reported by the output, if any.
+//
+// The logger keeps running. Messages sent by other goroutines while Flush is waiting
+// delay its return, so avoid calling it under a constant stream of messages.
+//
+// Does nothing if the logger is not started. Must not be called concurrently with Stop().
+func Flush() error {
+	return std.Flush()
+}
+
+// Sync flushes the logger like Flush() and exists for drop-in migration from zap:
+//
+//	defer asynclog.Sync()
+//
+// It is equivalent to Flush().
+func Sync() error {
+	return std.Flush()
+}
+
+// Flush blocks until every message sent before the call is written to the output. See Flush().
+func (l *Logger) Flush() error {
+	if !l.isStarted {
+		return nil
+	}
+
+	// Wait for the workers to take every queued message, then ask each one to write its batch.
+	// Workers that exited after too many restarts, or paused ones, no longer take any.
+	for l.queued()+l.overflow.pendingCount() > 0 && l.live.Load() > 0 && !l.gate.paused.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	var err error
+	reply := make(chan error)
+	for _, wk := range l.pool {
+		select {
+		case wk.flush <- reply:
+		case <-wk.done:
+			continue // exited after too many restarts, see SetWorkerRestarts()
+		}
+		if ferr := <-reply; err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// WaitIdle blocks until every message sent before the call is written to the output, without forcing
+// the workers to write their batches like Flush() does. The logger keeps running.
+//
+// Useful after a burst of logging to make sure everything is written before taking a snapshot:
+//
+//	runBatchJob()
+//	asynclog.WaitIdle()
+//	snapshotLogs()
+//
+// Returns once the queue is momentarily empty, at most about 500ms after the last message, since
+// the workers write their batches at least that often. Blocks while the logger is paused, and
+// until Flush() or Stop() with SetDeterministic(true), which turns off the periodic writes.
+//
+// Does nothing if the logger is not started. Must not be called concurrently with Stop().
+func WaitIdle() {
+	std.WaitIdle()
+}
+
+// WaitIdle blocks until every message sent before the call is written to the output. See WaitIdle().
+func (l *Logger) WaitIdle() {
+	if !l.isStarted {
+		return
+	}
+
+	for l.queued()+l.overflow.pendingCount() > 0 && l.live.Load() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, wk := range l.pool {
+		done := make(chan struct{})
+		select {
+		case wk.idle <- done:
+		case <-wk.done:
+			continue
+		}
+		select {
+		case <-done:
+		case <-wk.done:
+		}
+	}
+}
+
+// Sync flushes the logger like Flush(). See Sync().
+func (l *Logger) Sync() error {
+	return l.Flush()
+}
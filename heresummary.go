@@ -0,0 +1,79 @@
+package asynclog
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// hereSummaryInterval is how often the Here() summary is sent, see SetHereSummary()
+const hereSummaryInterval = time.Second
+
+var (
+	hereSummary     = false
+	hereSummaryHits atomic.Uint64
+	hereSummaryStop chan struct{} // closed by Stop()
+	hereSummaryDone chan struct{} // closed once the last summary is sent
+)
+
+// Sets whether Here() counts its hits instead of sending a message for each one. Default is false.
+//
+// Once a second, and when the logger stops, a single line with the hits since the previous one is sent:
+//
+//	Here hit 10423 times
+//
+// A hit is then a single atomic increment, cheap enough for the hottest loops.
+// Nothing is sent for an interval without hits. DebugHere() is not summarized, it still sends
+// its file and line on every call.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetHereSummary(b bool) {
+	if std.isStarted {
+		return
+	}
+	hereSummary = b
+}
+
+// startHereSummary starts sending the Here() summary, only Here() of the default logger is summarized
+func (l *Logger) startHereSummary() {
+	if !hereSummary || l.core != std.core {
+		return
+	}
+	hereSummaryStop = make(chan struct{})
+	hereSummaryDone = make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(hereSummaryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.sendHereSummary()
+			case <-stop:
+				l.sendHereSummary()
+				return
+			}
+		}
+	}(hereSummaryStop, hereSummaryDone)
+}
+
+// stopHereSummary sends the last summary and stops sending them
+func (l *Logger) stopHereSummary() {
+	if hereSummaryStop == nil || l.core != std.core {
+		return
+	}
+	close(hereSummaryStop)
+	<-hereSummaryDone
+	hereSummaryStop, hereSummaryDone = nil, nil
+}
+
+// sendHereSummary sends the hits counted since the previous summary, if any
+func (l *Logger) sendHereSummary() {
+	if n := hereSummaryHits.Swap(0); n > 0 {
+		l.sendLevel(hereLevel, here+" hit "+strconv.FormatUint(n, 10)+" times")
+	}
+}
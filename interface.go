@@ -0,0 +1,45 @@
+package asynclog
+
+// LevelLogger is the leveled logging interface implemented by *Logger and Nop().
+//
+// Libraries can accept a LevelLogger and let the application decide whether to pass
+// a running *Logger, the default one from Default(), or Nop() to silence them.
+type LevelLogger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// FormatLogger is a LevelLogger with the formatted Infof, Warnf and Errorf variants.
+type FormatLogger interface {
+	LevelLogger
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+var (
+	_ FormatLogger = (*Logger)(nil)
+	_ FormatLogger = nopLogger{}
+)
+
+// Default returns the Logger used by the package level functions.
+func Default() *Logger {
+	return std
+}
+
+// Nop returns a FormatLogger that discards everything without doing any work.
+func Nop() FormatLogger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string)          {}
+func (nopLogger) Info(string)           {}
+func (nopLogger) Warn(string)           {}
+func (nopLogger) Error(string)          {}
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
@@ -0,0 +1,87 @@
+//go:build linux
+
+package asynclog
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// journaldSocket is where systemd-journald receives entries in its native protocol
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldWriter sends every line written to it as a journal entry
+type journaldWriter struct {
+	conn  *net.UnixConn
+	ident string
+}
+
+// NewJournaldWriter returns a writer sending every line to the systemd journal as its own entry,
+// with the MESSAGE, PRIORITY and SYSLOG_IDENTIFIER fields. Only available on Linux.
+//
+//	jw, err := asynclog.NewJournaldWriter()
+//	if err != nil {
+//		return err
+//	}
+//	defer jw.Close()
+//	asynclog.SetOutput(jw)
+//
+// The priority is taken from the level tag, "[ERROR] disk full" is logged as err. Lines without a
+// full level tag, and every line with SetCompactLevel(true), are logged as info. Messages spanning
+// several lines, such as Stack(), become one entry per line. The identifier is the program name.
+// Leave SetTimestamps() and SetIncludeHostPID() off, the journal records both itself and the
+// prefixes would hide the level tag.
+//
+// Returns an error if journald is not running.
+func NewJournaldWriter() (io.WriteCloser, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldWriter{conn: conn, ident: filepath.Base(os.Args[0])}, nil
+}
+
+func (jw *journaldWriter) Write(p []byte) (int, error) {
+	var entry []byte
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte{'\n'}), []byte{'\n'}) {
+		entry = append(entry[:0], "MESSAGE="...)
+		entry = append(entry, line...)
+		entry = append(entry, "\nPRIORITY="...)
+		entry = strconv.AppendInt(entry, int64(journaldPriority(line)), 10)
+		entry = append(entry, "\nSYSLOG_IDENTIFIER="...)
+		entry = append(entry, jw.ident...)
+		entry = append(entry, '\n')
+		if _, err := jw.conn.Write(entry); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (jw *journaldWriter) Close() error {
+	return jw.conn.Close()
+}
+
+// journaldPriority maps the level tag of line to a syslog priority, info if it has none
+func journaldPriority(line []byte) int {
+	lvl, ok := lineLevel(line)
+	if !ok {
+		return 6
+	}
+	switch lvl {
+	case TraceLevel, DebugLevel:
+		return 7
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	case FatalLevel:
+		return 2
+	default:
+		return 6
+	}
+}
@@ -0,0 +1,105 @@
+package asynclog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the LatencyStats() buckets, the last bucket has no bound
+var latencyBounds = [...]time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// latencyHistogram records how long messages wait between being sent and being written
+type latencyHistogram struct {
+	enabled bool
+	count   atomic.Uint64
+	total   atomic.Int64 // nanoseconds
+	max     atomic.Int64 // nanoseconds
+	buckets [len(latencyBounds) + 1]atomic.Uint64
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	h.count.Add(1)
+	h.total.Add(int64(d))
+	for {
+		max := h.max.Load()
+		if int64(d) <= max || h.max.CompareAndSwap(max, int64(d)) {
+			break
+		}
+	}
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+	h.buckets[i].Add(1)
+}
+
+// LatencyBucket is the number of messages written within Le of being sent.
+// The last bucket of a Latency has an Le of 0 and counts every slower message.
+type LatencyBucket struct {
+	Le    time.Duration
+	Count uint64
+}
+
+// Latency describes how long messages waited in the queue and the workers' batches before being written.
+type Latency struct {
+	Count   uint64
+	Mean    time.Duration
+	Max     time.Duration
+	Buckets []LatencyBucket
+}
+
+// SetLatencyTracking sets whether the time between sending a message and writing it is
+// recorded, see LatencyStats(). Default is false.
+//
+// This shows whether the logger keeps up and how much delay the asynchronous design adds.
+// It costs a time.Now() per message.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetLatencyTracking(b bool) {
+	std.SetLatencyTracking(b)
+}
+
+// SetLatencyTracking sets whether the time between sending a message and writing it is recorded. See SetLatencyTracking().
+func (l *Logger) SetLatencyTracking(b bool) {
+	if l.isStarted {
+		return
+	}
+	l.latency.enabled = b
+}
+
+// LatencyStats returns the latency recorded since the logger was created. See SetLatencyTracking().
+//
+// Every field is zero unless latency tracking is enabled.
+func LatencyStats() Latency {
+	return std.LatencyStats()
+}
+
+// LatencyStats returns the latency recorded since the logger was created. See SetLatencyTracking().
+func (l *Logger) LatencyStats() Latency {
+	h := &l.latency
+	stats := Latency{
+		Count:   h.count.Load(),
+		Max:     time.Duration(h.max.Load()),
+		Buckets: make([]LatencyBucket, len(h.buckets)),
+	}
+	if stats.Count > 0 {
+		stats.Mean = time.Duration(h.total.Load() / int64(stats.Count))
+	}
+	for i := range h.buckets {
+		stats.Buckets[i].Count = h.buckets[i].Load()
+		if i < len(latencyBounds) {
+			stats.Buckets[i].Le = latencyBounds[i]
+		}
+	}
+	return stats
+}
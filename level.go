@@ -0,0 +1,361 @@
+package asynclog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// Level is the severity of a message sent to the logger.
+//
+// Messages below the minimum level set by SetLevel() are dropped before they are formatted.
+//
+// From most to least verbose: TraceLevel < DebugLevel < InfoLevel < WarnLevel < ErrorLevel < FatalLevel.
+// The default minimum is DebugLevel, so Trace messages are filtered out unless enabled.
+type Level int32
+
+const (
+	TraceLevel Level = iota - 1
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	FatalLevel
+)
+
+// String returns the name of the level, e.g. "INFO".
+func (l Level) String() string {
+	switch l {
+	case TraceLevel:
+		return "TRACE"
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARN"
+	case ErrorLevel:
+		return "ERROR"
+	case FatalLevel:
+		return "FATAL"
+	default:
+		return "LEVEL(" + toString(int(l)) + ")"
+	}
+}
+
+// tag returns the prefix written in front of leveled messages, e.g. "[INFO] " or "I " with SetCompactLevel(true)
+func (l Level) tag() string {
+	return string(l.appendTag(nil))
+}
+
+// appendTag appends the tag of the level to dst, see tag()
+func (l Level) appendTag(dst []byte) []byte {
+	name := l.String()
+	if compactLevel {
+		return append(dst, name[0], ' ')
+	}
+	start := len(dst)
+	dst = append(dst, '[')
+	dst = append(dst, name...)
+	dst = append(dst, "] "...)
+	for alignLevels && len(dst)-start < alignedTagWidth {
+		dst = append(dst, ' ')
+	}
+	return dst
+}
+
+// lineLevel returns the level of a written line starting with a full level tag, e.g. "[WARN] ".
+// Used by the writers for native OS logging to map lines to their severities.
+func lineLevel(line []byte) (Level, bool) {
+	for lvl := TraceLevel; lvl <= FatalLevel; lvl++ {
+		if bytes.HasPrefix(line, []byte("["+lvl.String()+"]")) {
+			return lvl, true
+		}
+	}
+	return 0, false
+}
+
+// alignedTagWidth is the width of the longest tag, "[ERROR] "
+const alignedTagWidth = len("[ERROR] ")
+
+var (
+	compactLevel = false
+	alignLevels  = false
+)
+
+// Sets whether level tags are padded to the same width so messages start at the same column. Default is false.
+//
+//	[INFO]  server started
+//	[ERROR] connection refused
+//
+// Has no effect with SetCompactLevel(true), single letter tags are always aligned.
+//
+// If the logger is already started, this function does nothing.
+func SetAlignLevels(b bool) {
+	if std.isStarted {
+		return
+	}
+	alignLevels = b
+}
+
+// Sets whether levels are written as a single letter, "T", "D", "I", "W", "E" and "F", instead of
+// the full "[TRACE]", "[DEBUG]", "[INFO]", "[WARN]", "[ERROR]" and "[FATAL]" tags. Default is false.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetCompactLevel(b bool) {
+	if std.isStarted {
+		return
+	}
+	compactLevel = b
+}
+
+// Sets the minimum level of messages that are sent to the logger. Default is DebugLevel.
+//
+// Unlike the other setters, SetLevel can be called while the logger is running.
+func SetLevel(l Level) {
+	std.SetLevel(l)
+}
+
+// GetLevel returns the current minimum level.
+func GetLevel() Level {
+	return std.GetLevel()
+}
+
+// Sets the minimum level of messages that are sent to the logger. See SetLevel().
+func (l *Logger) SetLevel(lvl Level) {
+	l.level.Store(int32(lvl))
+}
+
+// GetLevel returns the current minimum level of the logger.
+func (l *Logger) GetLevel() Level {
+	return Level(l.level.Load())
+}
+
+// WithLevel sets the minimum level to l while fn runs, then restores the previous level.
+//
+// Useful to raise verbosity for a single code path during an incident:
+//
+//	asynclog.WithLevel(asynclog.DebugLevel, func() {
+//		processOrder(id)
+//	})
+//
+// The level is not goroutine-local, it applies to every goroutine logging while fn runs.
+// Overlapping calls from different goroutines each restore the level they found.
+func WithLevel(l Level, fn func()) {
+	std.WithLevel(l, fn)
+}
+
+// WithLevel sets the minimum level to lvl while fn runs. See WithLevel().
+func (l *Logger) WithLevel(lvl Level, fn func()) {
+	prev := Level(l.level.Swap(int32(lvl)))
+	defer l.SetLevel(prev)
+	fn()
+}
+
+// enabled reports whether a message at level lvl would be sent to the logger.
+//
+// This is checked before any formatting so filtered messages cost nothing.
+func (l *Logger) enabled(lvl Level) bool {
+	return l.accepting() && lvl >= Level(l.level.Load())
+}
+
+// SetStderrThreshold sends every message at or above l to os.Stderr, and the rest
+// to the output set by SetOutput(), os.Stdout by default.
+//
+//	asynclog.SetStderrThreshold(asynclog.WarnLevel) // Warn and Error to stderr, Debug and Info to stdout
+//
+// Messages written to os.Stderr are not batched. Print() and other messages without a level
+// always go to the output.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetStderrThreshold(l Level) {
+	std.SetStderrThreshold(l)
+}
+
+// SetStderrThreshold sends every message at or above lvl to os.Stderr. See SetStderrThreshold().
+func (l *Logger) SetStderrThreshold(lvl Level) {
+	if l.isStarted {
+		return
+	}
+	l.stderrLevel = lvl
+	l.splitStderr = true
+}
+
+// sendLevel queues text sent at level lvl, routing it to os.Stderr if needed
+// and appending a stack trace from SetStackTraceLevel().
+//
+// Error and Fatal messages are queued on a separate channel that the workers drain first,
+// so they are not stuck behind a backlog of lower level messages. They can be written
+// before lower level messages sent earlier.
+func (l *Logger) sendLevel(lvl Level, text string) {
+	l.sendRecord(lvl, text, l.newRecord(lvl, text))
+}
+
+// sendRecord is sendLevel for a message whose record, if any, is built by the caller
+func (l *Logger) sendRecord(lvl Level, text string, rec *Record) {
+	if lvl >= stackTraceLevel {
+		trace := "\n" + callerStackTrace(defaultStackDepth)
+		text += trace
+		if rec != nil {
+			rec.Msg += trace
+		}
+	}
+	l.sendTraced(lvl, text, rec)
+}
+
+// sendTraced is sendRecord for a message that already carries its own stack trace, see ErrorWithStack()
+func (l *Logger) sendTraced(lvl Level, text string, rec *Record) {
+	m := message{text: text, urgent: lvl >= ErrorLevel && !l.determ, rec: rec}
+	if l.splitStderr && lvl >= l.stderrLevel {
+		m.w = os.Stderr
+	}
+	l.enqueue(m)
+}
+
+// Info sends a message tagged with "[INFO] " to the logger.
+func Info(msg string) {
+	std.Info(msg)
+}
+
+// Warn sends a message tagged with "[WARN] " to the logger.
+func Warn(msg string) {
+	std.Warn(msg)
+}
+
+// Error sends a message tagged with "[ERROR] " to the logger.
+func Error(msg string) {
+	std.Error(msg)
+}
+
+// Infof formats according to a format specifier and sends the result to the logger like Info().
+//
+// fmt.Sprintf() is only called if InfoLevel is enabled, so filtered calls cost nothing.
+// The level tag is written right before the formatted message, after the timestamp,
+// sequence number and host[pid] when they are enabled:
+//
+//	2024/01/02 03:04:05.000000 #000001 web-1[4242] [INFO] x=1
+func Infof(format string, args ...any) {
+	std.Infof(format, args...)
+}
+
+// Warnf formats according to a format specifier and sends the result to the logger like Warn().
+//
+// fmt.Sprintf() is only called if WarnLevel is enabled, so filtered calls cost nothing.
+// The level tag is written right before the formatted message, after the timestamp,
+// sequence number and host[pid] when they are enabled:
+//
+//	2024/01/02 03:04:05.000000 #000001 web-1[4242] [WARN] x=1
+func Warnf(format string, args ...any) {
+	std.Warnf(format, args...)
+}
+
+// Errorf formats according to a format specifier and sends the result to the logger like Error().
+//
+// fmt.Sprintf() is only called if ErrorLevel is enabled, so filtered calls cost nothing.
+// The level tag is written right before the formatted message, after the timestamp,
+// sequence number and host[pid] when they are enabled:
+//
+//	2024/01/02 03:04:05.000000 #000001 web-1[4242] [ERROR] x=1
+func Errorf(format string, args ...any) {
+	std.Errorf(format, args...)
+}
+
+// Info sends a message tagged with "[INFO] " to the logger.
+func (l *Logger) Info(msg string) {
+	l.leveled(InfoLevel, msg)
+}
+
+// Warn sends a message tagged with "[WARN] " to the logger.
+func (l *Logger) Warn(msg string) {
+	l.leveled(WarnLevel, msg)
+}
+
+// Error sends a message tagged with "[ERROR] " to the logger.
+func (l *Logger) Error(msg string) {
+	l.leveled(ErrorLevel, msg)
+}
+
+// Infof formats according to a format specifier and sends the result to the logger like Info().
+func (l *Logger) Infof(format string, args ...any) {
+	if !l.enabled(InfoLevel) {
+		return
+	}
+	l.sendLevel(InfoLevel, InfoLevel.tag()+fmt.Sprintf(format, args...))
+}
+
+// Warnf formats according to a format specifier and sends the result to the logger like Warn().
+func (l *Logger) Warnf(format string, args ...any) {
+	if !l.enabled(WarnLevel) {
+		return
+	}
+	l.sendLevel(WarnLevel, WarnLevel.tag()+fmt.Sprintf(format, args...))
+}
+
+// Errorf formats according to a format specifier and sends the result to the logger like Error().
+func (l *Logger) Errorf(format string, args ...any) {
+	if !l.enabled(ErrorLevel) {
+		return
+	}
+	l.sendLevel(ErrorLevel, ErrorLevel.tag()+fmt.Sprintf(format, args...))
+}
+
+// Trace sends a message tagged with "[TRACE] " to the logger.
+//
+// TraceLevel is below the default minimum level, enable it with SetLevel(TraceLevel).
+func Trace(msg string) {
+	std.Trace(msg)
+}
+
+// Fatal sends a message tagged with "[FATAL] " to the logger, stops the logger so every
+// pending message is written, then exits the program with os.Exit(1).
+//
+// Deferred functions are not run. The program exits even if the logger is not started.
+func Fatal(msg string) {
+	std.Fatal(msg)
+}
+
+// Fatalf formats according to a format specifier and sends the result to the logger like Fatal(),
+// then exits the program with os.Exit(1).
+func Fatalf(format string, args ...any) {
+	std.Fatalf(format, args...)
+}
+
+// Trace sends a message tagged with "[TRACE] " to the logger.
+func (l *Logger) Trace(msg string) {
+	l.leveled(TraceLevel, msg)
+}
+
+// Fatal sends a message tagged with "[FATAL] " to the logger, stops it and exits the program. See Fatal().
+func (l *Logger) Fatal(msg string) {
+	l.leveled(FatalLevel, msg)
+	l.exit()
+}
+
+// Fatalf formats according to a format specifier and sends the result to the logger like Fatal().
+func (l *Logger) Fatalf(format string, args ...any) {
+	if l.enabled(FatalLevel) {
+		l.sendLevel(FatalLevel, FatalLevel.tag()+fmt.Sprintf(format, args...))
+	}
+	l.exit()
+}
+
+// exit stops the logger so pending messages are written, then exits the program
+func (l *Logger) exit() {
+	l.Stop()
+	os.Exit(1)
+}
+
+func (l *Logger) leveled(lvl Level, msg string) {
+	if !l.enabled(lvl) {
+		return
+	}
+	l.sendLevel(lvl, lvl.tag()+msg)
+}
@@ -0,0 +1,65 @@
+package asynclog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// writeField writes " key=value" to sb in logfmt, quoting the value when needed.
+//
+// This is how every key=value pair is rendered, by Entry.Field(), PrintContext() and PrintLogfmt().
+func writeField(sb *strings.Builder, key string, val any) {
+	sb.WriteByte(' ')
+	sb.WriteString(key)
+	sb.WriteByte('=')
+	if redacted(key) {
+		sb.WriteString(redactedValue)
+		return
+	}
+	writeLogfmtValue(sb, toString(val))
+}
+
+// writeLogfmtValue writes s, quoted if needed, see needsQuote()
+func writeLogfmtValue(sb *strings.Builder, s string) {
+	if needsQuote(s) {
+		sb.WriteString(strconv.Quote(s))
+		return
+	}
+	sb.WriteString(s)
+}
+
+// needsQuote reports whether a logfmt value is empty or contains spaces, quotes, '=' or control characters
+func needsQuote(s string) bool {
+	return s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == 0x7f
+	})
+}
+
+// PrintLogfmt sends alternating keys and values to the logger in logfmt, a format
+// popular with Heroku, Loki and Grafana:
+//
+//	asynclog.PrintLogfmt("method", "GET", "path", "/users", "took", 12*time.Millisecond, "msg", "not found")
+//	// Output: method=GET path=/users took=12ms msg="not found"
+//
+// Values are converted like PrintArgs() and quoted when they contain spaces, quotes or '='.
+// A key without a value is written with an empty value.
+func PrintLogfmt(keysAndValues ...any) {
+	std.PrintLogfmt(keysAndValues...)
+}
+
+// PrintLogfmt sends alternating keys and values to the logger in logfmt. See PrintLogfmt().
+func (l *Logger) PrintLogfmt(keysAndValues ...any) {
+	if !l.accepting() {
+		return
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var val any = ""
+		if i+1 < len(keysAndValues) {
+			val = keysAndValues[i+1]
+		}
+		writeField(&sb, toString(keysAndValues[i]), val)
+	}
+	l.send(strings.TrimPrefix(sb.String(), " "))
+}
@@ -0,0 +1,483 @@
+package asynclog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Logger is an independent logger with its own message channel, workers, output, level and lifecycle.
+//
+// The package level functions use a default Logger, so most programs never need one.
+// Create a Logger with NewLogger() or fetch a named one from anywhere with Get().
+// Loggers returned by With() and WithContext() share everything but their fields with the Logger they derive from.
+//
+// The configuration methods behave like their package level counterparts, they must be called
+// before Start() and do nothing while the logger is running.
+type Logger struct {
+	*core
+	fields    string  // " key=value" pairs appended to every message, see With()
+	recFields []Field // the same pairs, passed to a RecordWriter as Record.Fields
+}
+
+// core is the state of a Logger, shared with the loggers derived from it by With() and WithContext()
+type core struct {
+	buffer    int
+	workers   int
+	out       lockedWriter
+	messages  chan message
+	urgent    chan message // Error and Fatal messages, taken by the workers first
+	stop      chan struct{}
+	pool      []*worker
+	running   sync.WaitGroup // workers that have not exited yet
+	live      atomic.Int32   // see LiveWorkers()
+	restarts  restartLimit
+	isStarted bool
+	startTime time.Time
+	determ    bool         // see SetDeterministic()
+	level     atomic.Int32 // DebugLevel by default
+
+	queue      byteLimit
+	highWater  highWater
+	dropPolicy DropPolicy
+	dropped    atomic.Uint64
+	taps       taps
+	gate       pauseGate
+	outChan    chan<- string // see SetOutputChannel()
+	latency    latencyHistogram
+	throughput throughput
+	pre        preStart
+	tagFilter  tagFilter
+	coalesce   coalescer
+	overflow   overflowSpill
+	limit      rateLimit
+	records    recordSink
+	toMu       sync.Mutex // see PrintTo()
+
+	middlewares []Middleware  // see Use()
+	budget      int           // see SetMemoryBudget()
+	seq         atomic.Uint64 // see SetSequenceNumbers()
+
+	shards     []chan message // see SetSharding(), nil with a single channel
+	shardCount int
+	ordered    chan message // the channel of the messages that keep their order, see makeChannels()
+
+	stderrLevel Level // see SetStderrThreshold()
+	splitStderr bool
+	autoWorkers bool // see SetWorkersAuto()
+	doubleStart sync.Once
+}
+
+// std is the default Logger used by the package level functions.
+var std = NewLogger()
+
+// NewLogger returns a stopped Logger with the default configuration:
+// a buffer of 100 messages, 15 workers and os.Stdout as output.
+func NewLogger() *Logger {
+	return &Logger{core: &core{
+		buffer:   100,
+		workers:  15,
+		out:      lockedWriter{w: os.Stdout},
+		restarts: restartLimit{max: 5, window: time.Minute},
+	}}
+}
+
+// Sets the buffer limit to the messages channel. See SetBuffer().
+//
+// The channel is allocated by the first Start() and reused by every following Start()
+// as long as the buffer size is unchanged, so restarting a Logger does not allocate a new one.
+func (l *Logger) SetBuffer(b int) {
+	if l.isStarted {
+		return
+	}
+	l.buffer = b
+}
+
+// Takes an io.Writer to redirect logs to a file or other destination. See SetOutput().
+func (l *Logger) SetOutput(w io.Writer) {
+	if l.isStarted {
+		return
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	l.out.swap(w)
+}
+
+// Sets the number of worker goroutines for message consumption. See SetWorkers().
+func (l *Logger) SetWorkers(w int) {
+	if l.isStarted {
+		return
+	}
+	l.workers = w
+	l.autoWorkers = false
+}
+
+// Sets the number of workers to runtime.GOMAXPROCS(0) at Start(). See SetWorkersAuto().
+func (l *Logger) SetWorkersAuto() {
+	if l.isStarted {
+		return
+	}
+	l.autoWorkers = true
+}
+
+// Sets whether the logger writes a reproducible byte stream. See SetDeterministic().
+func (l *Logger) SetDeterministic(b bool) {
+	if l.isStarted {
+		return
+	}
+	l.determ = b
+}
+
+// Start initializes the message channel and worker goroutines of the logger. See Start().
+func (l *Logger) Start() {
+	if l.isStarted {
+		l.warnDoubleStart()
+		return
+	}
+	if err := l.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "asynclog: "+err.Error()+", clamping to the minimum")
+		l.workers = max(l.workers, 1)
+		l.buffer = max(l.buffer, 0)
+	}
+	l.start()
+}
+
+// ErrInvalidConfig is returned by StartErr() when the logger is configured with nonsensical values.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// StartErr is like Start() but returns an error instead of clamping an invalid configuration.
+//
+// When an error is returned the logger is not started. The error wraps ErrInvalidConfig.
+func (l *Logger) StartErr() error {
+	if l.isStarted {
+		l.warnDoubleStart()
+		return nil
+	}
+	if err := l.validate(); err != nil {
+		return err
+	}
+	l.start()
+	return nil
+}
+
+// warnDoubleStart tells once that Start() was called while the logger is running, since
+// the configuration set since the first Start() is silently ignored otherwise.
+func (l *Logger) warnDoubleStart() {
+	l.doubleStart.Do(func() {
+		fmt.Fprintln(os.Stderr, "asynclog: Start called while already running; config ignored")
+	})
+}
+
+// validate checks the configuration set before Start()
+func (l *Logger) validate() error {
+	if !l.autoWorkers && l.workers < 1 {
+		return fmt.Errorf("%w: workers must be at least 1, got %d", ErrInvalidConfig, l.workers)
+	}
+	if l.buffer < 0 {
+		return fmt.Errorf("%w: buffer must not be negative, got %d", ErrInvalidConfig, l.buffer)
+	}
+	return nil
+}
+
+func (l *Logger) start() {
+	l.stop = make(chan struct{})
+	l.startTime = now()
+	if includeHostPID {
+		hostPID()
+	}
+	debugCache.Clear()
+	debugCacheLen.Store(0)
+	workers := l.workers
+	if l.autoWorkers {
+		workers = min(runtime.GOMAXPROCS(0), maxAutoWorkers)
+	}
+	if l.determ {
+		workers = 1
+	}
+	l.makeChannels(workers)
+	l.pool = make([]*worker, workers)
+	l.isStarted = true
+	l.running.Add(len(l.pool))
+	l.live.Add(int32(len(l.pool)))
+	for i := range l.pool {
+		l.pool[i] = newWorker(l, l.shard(i))
+		go func(wk *worker) {
+			defer l.running.Done()
+			l.supervise(wk)
+		}(l.pool[i])
+	}
+	l.limit.block = l.dropPolicy == Block
+	l.limit.last = time.Time{}
+	l.startOverflow()
+	l.startHereSummary()
+	l.queueBuffered()
+}
+
+// Signals the workers of the logger to write the remaining messages and waits for them to exit. See Stop().
+func (l *Logger) Stop() {
+	if !l.isStarted {
+		return
+	}
+	l.stopHereSummary()
+	l.flushCoalesced()
+	l.stopOverflow()
+	l.isStarted = false
+	close(l.stop)
+	l.running.Wait()
+}
+
+// StopErr is like Stop() but returns ErrNotStarted if the logger was not running. See StopErr().
+func (l *Logger) StopErr() error {
+	if !l.isStarted {
+		return ErrNotStarted
+	}
+	l.Stop()
+	return nil
+}
+
+// StartTime returns when the logger was last started, or the zero time if it is not started. See StartTime().
+func (l *Logger) StartTime() time.Time {
+	if !l.isStarted {
+		return time.Time{}
+	}
+	return l.startTime
+}
+
+// Uptime returns how long the logger has been running since Start(), or 0 if it is not started. See Uptime().
+func (l *Logger) Uptime() time.Duration {
+	if !l.isStarted {
+		return 0
+	}
+	return now().Sub(l.startTime)
+}
+
+// message is a single message in the channel of a Logger
+type message struct {
+	text string
+	time time.Time // when the message was sent, only set with SetTimestamps(true), SetLatencyTracking(true) or SetRecordWriter()
+	w    io.Writer // written to w instead of the output when set, see PrintTo()
+
+	urgent bool          // sent at ErrorLevel or above, see sendLevel()
+	rec    *Record       // only set with SetRecordWriter()
+	done   chan struct{} // closed once written or dropped, see PrintWithDone()
+}
+
+// send queues text for the workers. Callers check that the logger is accepting messages.
+func (l *Logger) send(text string) {
+	l.enqueue(message{text: text})
+}
+
+// enqueue queues m for the workers. Every message goes through here or enqueueTimed().
+func (l *Logger) enqueue(m message) {
+	if timestamps || l.latency.enabled || l.records.w != nil {
+		// Taken here rather than by the workers, which may write the message much later
+		m.time = now()
+	}
+	l.enqueueTimed(m)
+}
+
+// enqueueTimed is enqueue for a message whose time is already set
+func (l *Logger) enqueueTimed(m message) {
+	if l.records.w != nil {
+		if m.rec == nil {
+			m.rec = &Record{Level: InfoLevel, Msg: m.text}
+		}
+		m.rec.Fields = append(m.rec.Fields, l.recFields...)
+		redactFields(m.rec.Fields)
+	}
+	if l.fields != "" {
+		// After the first line, so the fields stay on the line of the message rather than after a stack trace
+		if i := strings.IndexByte(m.text, '\n'); i >= 0 {
+			m.text = m.text[:i] + l.fields + m.text[i:]
+		} else {
+			m.text += l.fields
+		}
+	}
+	if includeHostPID {
+		m.text = hostPID() + m.text
+	}
+	if sequenceNumbers {
+		m.text = l.sequence() + m.text
+	}
+	if !l.isStarted {
+		l.sendBeforeStart(m)
+		return
+	}
+	if !l.acquire(m) {
+		return
+	}
+	l.push(m)
+}
+
+// sequence returns the "#000123 " prefix of the next message, see SetSequenceNumbers()
+func (l *Logger) sequence() string {
+	var b [24]byte
+	n := l.seq.Add(1)
+	buf := append(b[:0], '#')
+	for p := uint64(100000); p > 1 && n < p; p /= 10 {
+		buf = append(buf, '0')
+	}
+	buf = strconv.AppendUint(buf, n, 10)
+	return string(append(buf, ' '))
+}
+
+// Print sends a string to the messages channel if the logger is started.
+func (l *Logger) Print(msg string) {
+	if !l.accepting() {
+		return
+	}
+	l.send(msg)
+}
+
+// PrintTo sends msg to the logger to be written to w instead of the output. See PrintTo().
+func (l *Logger) PrintTo(w io.Writer, msg string) {
+	if !l.accepting() {
+		return
+	}
+	l.enqueue(message{text: msg, w: w})
+}
+
+// PrintArgs concatenates args and sends the result to the logger. See PrintArgs().
+func (l *Logger) PrintArgs(args ...any) {
+	if !l.accepting() {
+		return
+	}
+
+	if len(args) == 1 {
+		l.send(toString(args[0]))
+		return
+	}
+
+	sargs := make([]string, len(args))
+	for i, arg := range args {
+		sargs[i] = toString(arg)
+	}
+
+	totalLen := 0
+	for _, s := range sargs {
+		totalLen += len(s)
+	}
+
+	var sb strings.Builder
+	sb.Grow(totalLen)
+	for _, s := range sargs {
+		sb.WriteString(s)
+	}
+
+	l.send(sb.String())
+}
+
+// PrintMany sends every message of msgs to the logger, in order. See PrintMany().
+func (l *Logger) PrintMany(msgs []string) {
+	if !l.accepting() || len(msgs) == 0 {
+		return
+	}
+	var t time.Time
+	if timestamps || l.latency.enabled || l.records.w != nil {
+		t = now()
+	}
+	for _, msg := range msgs {
+		l.enqueueTimed(message{text: msg, time: t})
+	}
+}
+
+// Join converts args to strings, joins them with sep and sends the result to the logger. See Join().
+func (l *Logger) Join(sep string, args ...any) {
+	if !l.accepting() {
+		return
+	}
+
+	sb := builderPool.Get().(*strings.Builder)
+	defer func() {
+		sb.Reset()
+		builderPool.Put(sb)
+	}()
+
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(toString(arg))
+	}
+	l.send(sb.String())
+}
+
+// debug sends msg prepended with the file and line number of a caller, filtered at lvl.
+//
+// skip is the number of stack frames to ascend, with 0 identifying the caller of debug.
+func (l *Logger) debug(lvl Level, skip int, msg string) {
+	if !l.enabled(lvl) {
+		return
+	}
+	info := debugInfo(skip + 1)
+	rec := l.newRecord(lvl, msg)
+	if rec != nil && info != nil {
+		rec.File, rec.Line = info.file, info.line
+	}
+
+	if info != nil {
+		msg = info.String() + " " + msg
+	} else {
+		msg = "ISSUE DETERMINING RUNTIME CALLER: " + msg
+	}
+	if debugElapsed {
+		// With the default clock Sub uses the monotonic reading, unaffected by wall clock changes
+		msg = "+" + strconv.FormatFloat(now().Sub(l.startTime).Seconds(), 'f', 6, 64) + "s " + msg
+	}
+	l.sendRecord(lvl, msg, rec)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Logger{}
+)
+
+// Get returns the Logger registered under name, creating a new one with NewLogger() if needed.
+//
+// Loggers are independent, each one has to be configured and started on its own. The output,
+// level, buffer, workers and the other Logger methods apply to that Logger only, while formatting
+// settings such as SetTimestamps() are package wide and shared by every Logger:
+//
+//	audit := asynclog.Get("audit")
+//	audit.SetOutput(auditFile)
+//	audit.Start()
+//	defer audit.Stop()
+//
+//	// anywhere else in the program
+//	asynclog.Get("audit").Print("user 42 logged in")
+//
+// Safe for concurrent use.
+func Get(name string) *Logger {
+	return GetOrCreate(name)
+}
+
+// GetOrCreate is like Get() but calls opts on the Logger when it is created.
+//
+// opts are not called if a Logger is already registered under name.
+//
+//	asynclog.GetOrCreate("audit", func(l *asynclog.Logger) {
+//		l.SetOutput(auditFile)
+//		l.Start()
+//	})
+func GetOrCreate(name string, opts ...func(*Logger)) *Logger {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if l, ok := registry[name]; ok {
+		return l
+	}
+	l := NewLogger()
+	for _, opt := range opts {
+		opt(l)
+	}
+	registry[name] = l
+	return l
+}
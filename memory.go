@@ -0,0 +1,46 @@
+package asynclog
+
+// minWorkerBuffer is the smallest batch buffer of a worker under a memory budget, see SetMemoryBudget()
+const minWorkerBuffer = 4 * 1024
+
+// SetMemoryBudget bounds the memory held by the logger to about n bytes, for memory constrained
+// environments. Default is 0, no budget: each worker holds up to 128KB of buffers even when idle.
+//
+//	asynclog.SetMemoryBudget(1 << 20) // 1MB
+//
+// Half of the budget bounds the queued messages like SetMaxQueuedBytes(), unless it is already set lower.
+// The other half is split between the workers for their batch buffers, each getting at least 4KB,
+// so batches are written sooner. Idle workers also release their batch buffer on every periodic
+// write instead of keeping it for the next burst.
+//
+// A smaller budget trades throughput for memory, since smaller batches mean more writes.
+// Calling SetMaxQueuedBytes() afterwards overrides the queue bound.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetMemoryBudget(n int) {
+	std.SetMemoryBudget(n)
+}
+
+// SetMemoryBudget bounds the memory held by the logger to about n bytes. See SetMemoryBudget().
+func (l *Logger) SetMemoryBudget(n int) {
+	if l.isStarted {
+		return
+	}
+	l.budget = max(n, 0)
+	if half := l.budget / 2; half > 0 && (l.queue.max == 0 || l.queue.max > half) {
+		l.queue.max = half
+	}
+}
+
+// workerBuffer returns the size of the batch buffer of each worker, see SetMemoryBudget()
+func (l *Logger) workerBuffer() int {
+	if l.budget == 0 || len(l.pool) == 0 {
+		return bufferSize
+	}
+	// Each worker holds its batch buffer and the buffer of its bufio.Writer
+	return min(max(l.budget/2/len(l.pool)/2, minWorkerBuffer), bufferSize)
+}
@@ -0,0 +1,54 @@
+package asynclog
+
+// Middleware wraps the step writing a line, like an http middleware wraps a handler.
+//
+// It returns a function receiving each line, which can call next with the line as is or
+// transformed, call next several times to split it, or not call next to drop it.
+type Middleware func(next func(string)) func(string)
+
+// Use adds middlewares transforming every message in the workers, just before it is written.
+// Middlewares run in the order they are added, the first one receives the message as sent:
+//
+//	asynclog.Use(
+//		func(next func(string)) func(string) {
+//			return func(line string) {
+//				if !strings.Contains(line, "healthcheck") {
+//					next(line)
+//				}
+//			}
+//		},
+//		func(next func(string)) func(string) {
+//			return func(line string) { next(strings.ToUpper(line)) }
+//		},
+//	)
+//
+// Lines are transformed before the timestamp and line terminator are added, and before
+// they reach Tail() subscribers and the output channel. Each worker builds its own chain,
+// so the functions run on every worker concurrently and must be safe for concurrent use.
+// They must not log to the same logger, which could block the workers.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func Use(mw ...Middleware) {
+	std.Use(mw...)
+}
+
+// Use adds middlewares transforming every message in the workers. See Use().
+func (l *Logger) Use(mw ...Middleware) {
+	if l.isStarted {
+		return
+	}
+	l.middlewares = append(l.middlewares, mw...)
+}
+
+// chain composes the middlewares around last, the first middleware being the outermost
+func chain(mw []Middleware, last func(string)) func(string) {
+	next := last
+	for i := len(mw) - 1; i >= 0; i-- {
+		next = mw[i](next)
+	}
+	return next
+}
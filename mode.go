@@ -0,0 +1,62 @@
+package asynclog
+
+import "time"
+
+// Mode is a preset of settings for an environment, see SetMode().
+type Mode int
+
+const (
+	// Dev is readable output for development: every level from Debug, aligned level tags,
+	// short local timestamps and a stack trace on warnings and errors.
+	Dev Mode = iota
+	// Prod is compact output for production: Info and above, single letter levels,
+	// RFC 3339 timestamps, stack traces on errors only and the host and process ID on every line.
+	Prod
+)
+
+// SetMode applies the preset settings of m, instead of calling each setter:
+//
+//	if os.Getenv("ENV") == "production" {
+//		asynclog.SetMode(asynclog.Prod)
+//	} else {
+//		asynclog.SetMode(asynclog.Dev)
+//	}
+//	asynclog.Start()
+//
+//	// Dev:  15:04:05.000 [WARN]  cache miss
+//	// Prod: 2024-01-02T15:04:05.123456789+01:00 web-3[4182] W cache miss
+//
+// The preset is made of SetLevel(), SetTimestamps(), SetTimeFormat(), SetAlignLevels(),
+// SetCompactLevel(), SetStackTraceLevel() and SetIncludeHostPID(). Calling any of them
+// afterwards overrides that part of the preset. The logger has no color nor JSON output,
+// see SetRecordWriter() to encode records as JSON.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetMode(m Mode) {
+	if std.isStarted {
+		return
+	}
+
+	SetTimestamps(true)
+	switch m {
+	case Dev:
+		SetLevel(DebugLevel)
+		SetTimeFormat("15:04:05.000")
+		SetAlignLevels(true)
+		SetCompactLevel(false)
+		SetStackTraceLevel(WarnLevel)
+		SetIncludeHostPID(false)
+
+	case Prod:
+		SetLevel(InfoLevel)
+		SetTimeFormat(time.RFC3339Nano)
+		SetAlignLevels(false)
+		SetCompactLevel(true)
+		SetStackTraceLevel(ErrorLevel)
+		SetIncludeHostPID(true)
+	}
+}
@@ -0,0 +1,158 @@
+package asynclog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// errorHandler is called by the workers when writing to the output fails
+var errorHandler = defaultErrorHandler
+
+func defaultErrorHandler(err error) {
+	fmt.Fprintln(os.Stderr, "asynclog: "+err.Error())
+}
+
+// Sets the function called when writing to the output fails, so a full disk or a dropped
+// network connection does not silently lose messages. By default the error is written to os.Stderr.
+//
+// fn is called from the worker goroutines and must be safe for concurrent use.
+// A nil fn ignores every error.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetErrorHandler(fn func(error)) {
+	if std.isStarted {
+		return
+	}
+	if fn == nil {
+		fn = func(error) {}
+	}
+	errorHandler = fn
+}
+
+// lockedWriter is the output of a Logger shared by all of its workers.
+//
+// Each worker writes whole batches of lines, the lock keeps batches from different
+// workers from interleaving and lets the output be swapped while the logger is running.
+type lockedWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	fo  failover  // see SetFailoverBuffer()
+	sec secondary // see SetFailoverWriter()
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.sec.w != nil {
+		return lw.sec.write(lw.w, p)
+	}
+	if lw.fo.max > 0 {
+		return lw.fo.write(lw.w, p)
+	}
+	return lw.w.Write(p)
+}
+
+// swap replaces the output with w and returns the previous one
+func (lw *lockedWriter) swap(w io.Writer) io.Writer {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	old := lw.w
+	lw.w = w
+	return old
+}
+
+// current returns the output
+func (lw *lockedWriter) current() io.Writer {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w
+}
+
+// RotateOutput writes every pending message to the current output, swaps it for w
+// and returns the previous output so it can be closed.
+//
+// This is the building block for log rotation: every message sent before the call is
+// written to the old output, and the workers only ever write whole lines, so no line is
+// split between the two. The error is the one returned by flushing the old output, the
+// swap happens regardless. A nil w is treated as io.Discard.
+//
+//	old, err := asynclog.RotateOutput(newFile)
+//	if err == nil {
+//		old.(io.Closer).Close()
+//	}
+//
+// Works whether or not the logger is started. Must not be called concurrently with Stop().
+func RotateOutput(w io.Writer) (io.Writer, error) {
+	return std.RotateOutput(w)
+}
+
+// RotateOutput writes every pending message to the current output and swaps it for w. See RotateOutput().
+func (l *Logger) RotateOutput(w io.Writer) (io.Writer, error) {
+	if w == nil {
+		w = io.Discard
+	}
+	err := l.Flush()
+	return l.out.swap(w), err
+}
+
+// SetOutputChannel sends every message to ch in addition to writing it to the output,
+// so it can be post-processed by a custom pipeline. A nil ch stops sending. Default is nil.
+//
+// Messages are sent as written to the output, timestamp included, without the trailing newline.
+// The workers never wait on ch: a message that does not fit is dropped and counted by Dropped(),
+// so give ch enough buffer to absorb bursts. To only use the channel, combine it with SetOutput(io.Discard).
+//
+//	lines := make(chan string, 1024)
+//	asynclog.SetOutputChannel(lines)
+//	go func() {
+//		for line := range lines {
+//			pipeline.Process(line)
+//		}
+//	}()
+//
+// ch is never closed by the logger.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetOutputChannel(ch chan<- string) {
+	std.SetOutputChannel(ch)
+}
+
+// SetOutputChannel sends every message to ch in addition to writing it to the output. See SetOutputChannel().
+func (l *Logger) SetOutputChannel(ch chan<- string) {
+	if l.isStarted {
+		return
+	}
+	l.outChan = ch
+}
+
+// writerMaxLine is the maximum length of a written line, 0 is unlimited
+var writerMaxLine = 0
+
+// Sets the maximum length in bytes of a line written to the output. Longer lines are cut
+// by the workers just before being written. Default is 0, unlimited.
+//
+// A last line of defense for log collectors with a line length limit, it applies to every
+// message, including the ones sent through Writer(). The timestamp counts in the length,
+// the line terminator does not. Tail() subscribers and SetOutputChannel() receive the full message.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetWriterMaxLine(n int) {
+	if std.isStarted {
+		return
+	}
+	writerMaxLine = n
+}
@@ -0,0 +1,230 @@
+package asynclog
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// overflowSpill keeps the messages sent while the channel is full in a temporary file,
+// and feeds them back to the channel as the workers catch up. See SetOverflowSpill().
+type overflowSpill struct {
+	dir string // "" disables spilling
+
+	mu      sync.Mutex
+	f       *os.File // created by the first spilled message
+	roff    int64    // next record to read
+	woff    int64    // end of the written records
+	pending int      // records written but not queued yet
+
+	wake chan struct{} // a record was written
+	quit chan struct{} // closed by Stop()
+	done chan struct{} // closed when the feeder exits
+}
+
+// spillHeader is the size of the header of a record: the time in Unix nanoseconds and the text length
+const spillHeader = 12
+
+// Sets a directory where messages sent while the buffer is full are kept in a temporary file,
+// instead of blocking the sender. Default is "", sending blocks.
+//
+//	asynclog.SetOverflowSpill(os.TempDir())
+//
+// Once a message is spilled, following messages are spilled too until the file is fed back
+// to the workers, so the order of the messages is kept. Nothing is lost during a burst,
+// at the cost of disk I/O on the sending side while it lasts:
+//   - the file grows as long as messages are sent faster than they are written, bounded only by the disk
+//   - a spilled message can be written long after it was sent
+//   - spilled messages are lost if the program crashes before they are written
+//
+// If writing the file fails, the error is reported to the error handler and sending blocks as usual.
+// The file is removed by Stop(), once every spilled message is written.
+//
+// Only applies with the Block drop policy. Error and Fatal messages and messages sent with PrintTo() are never spilled.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetOverflowSpill(dir string) {
+	std.SetOverflowSpill(dir)
+}
+
+// Sets a directory where messages sent while the buffer is full are kept. See SetOverflowSpill().
+func (l *Logger) SetOverflowSpill(dir string) {
+	if l.isStarted {
+		return
+	}
+	l.overflow.dir = dir
+}
+
+// startOverflow starts the goroutine feeding spilled messages back to the channel
+func (l *Logger) startOverflow() {
+	if l.overflow.dir == "" {
+		return
+	}
+	l.overflow.wake = make(chan struct{}, 1)
+	l.overflow.quit = make(chan struct{})
+	l.overflow.done = make(chan struct{})
+	go l.feedOverflow()
+}
+
+// stopOverflow waits until every spilled message is queued, then removes the file
+func (l *Logger) stopOverflow() {
+	if l.overflow.dir == "" {
+		return
+	}
+	close(l.overflow.quit)
+	<-l.overflow.done
+
+	sp := &l.overflow
+	if sp.f != nil {
+		sp.f.Close()
+		os.Remove(sp.f.Name())
+		sp.f = nil
+		sp.roff, sp.woff = 0, 0
+	}
+}
+
+// trySpill sends m to the channel, or to the file if it is full or earlier messages are still
+// in the file. Returns false if m has to be sent the usual way.
+func (l *Logger) trySpill(m message) bool {
+	if l.overflow.dir == "" || m.urgent || m.w != nil || m.done != nil || l.dropPolicy != Block {
+		return false
+	}
+	if l.overflow.pendingCount() == 0 {
+		select {
+		case l.ordered <- m:
+			return true
+		default:
+		}
+	}
+	if err := l.overflow.write(m); err != nil {
+		errorHandler(err)
+		return false
+	}
+	// The feeder takes the bytes back when it queues the message
+	l.queue.release(len(m.text))
+	return true
+}
+
+// pendingCount returns the number of spilled messages not queued yet
+func (sp *overflowSpill) pendingCount() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.pending
+}
+
+// write appends m to the file
+func (sp *overflowSpill) write(m message) error {
+	rec := make([]byte, spillHeader, spillHeader+len(m.text))
+	var t int64
+	if !m.time.IsZero() {
+		t = m.time.UnixNano()
+	}
+	binary.LittleEndian.PutUint64(rec, uint64(t))
+	binary.LittleEndian.PutUint32(rec[8:], uint32(len(m.text)))
+	rec = append(rec, m.text...)
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.f == nil {
+		f, err := os.CreateTemp(sp.dir, "asynclog-spill-*")
+		if err != nil {
+			return err
+		}
+		sp.f = f
+	}
+	if _, err := sp.f.WriteAt(rec, sp.woff); err != nil {
+		return err
+	}
+	sp.woff += int64(len(rec))
+	sp.pending++
+
+	select {
+	case sp.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// read returns the next spilled message. On error, the records left are dropped and their count returned.
+func (sp *overflowSpill) read() (m message, ok bool, lost int, err error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.roff == sp.woff {
+		return message{}, false, 0, nil
+	}
+
+	var hdr [spillHeader]byte
+	if _, err := sp.f.ReadAt(hdr[:], sp.roff); err != nil {
+		return message{}, false, sp.reset(), err
+	}
+	text := make([]byte, binary.LittleEndian.Uint32(hdr[8:]))
+	if _, err := sp.f.ReadAt(text, sp.roff+spillHeader); err != nil && err != io.EOF {
+		return message{}, false, sp.reset(), err
+	}
+	sp.roff += spillHeader + int64(len(text))
+
+	m = message{text: string(text)}
+	if t := int64(binary.LittleEndian.Uint64(hdr[:])); t != 0 {
+		m.time = time.Unix(0, t)
+	}
+	return m, true, 0, nil
+}
+
+// queued marks a message returned by read() as queued, emptying the file once every record is.
+// Until then new messages keep going to the file, so they are not queued before the spilled ones.
+func (sp *overflowSpill) queued() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.pending--
+	if sp.pending == 0 {
+		sp.reset()
+	}
+}
+
+// reset empties the file and returns the number of records dropped
+func (sp *overflowSpill) reset() int {
+	lost := sp.pending
+	sp.pending = 0
+	sp.roff, sp.woff = 0, 0
+	sp.f.Truncate(0)
+	return lost
+}
+
+// feedOverflow queues the spilled messages as the workers make room, until Stop()
+func (l *Logger) feedOverflow() {
+	sp := &l.overflow
+	defer close(sp.done)
+	for {
+		select {
+		case <-sp.wake:
+		case <-sp.quit:
+			l.feedAll()
+			return
+		}
+		l.feedAll()
+	}
+}
+
+// feedAll queues every spilled message, blocking until the workers take them
+func (l *Logger) feedAll() {
+	for {
+		m, ok, lost, err := l.overflow.read()
+		if err != nil {
+			l.dropped.Add(uint64(lost))
+			errorHandler(err)
+			return
+		}
+		if !ok {
+			return
+		}
+		l.queue.acquire(len(m.text))
+		l.ordered <- m
+		l.overflow.queued()
+	}
+}
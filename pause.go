@@ -0,0 +1,79 @@
+package asynclog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pauseGate holds the workers of a Logger back while it is paused
+type pauseGate struct {
+	paused  atomic.Bool // checked by the workers without locking
+	mu      sync.Mutex
+	resumed chan struct{} // closed by Resume()
+}
+
+// wait returns a channel closed on Resume() if the logger is paused, nil otherwise
+func (g *pauseGate) wait() <-chan struct{} {
+	if !g.paused.Load() {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed
+}
+
+// Pause stops the workers from writing until Resume() is called.
+//
+// Messages sent while paused keep queuing in the buffer. Once it is full, sending blocks or
+// drops messages according to SetDropPolicy(), so combine it with DropNewest to silence a
+// known chatty maintenance window without slowing it down:
+//
+//	asynclog.Pause()
+//	runMaintenance()
+//	asynclog.Resume()
+//
+// Flush() while paused only writes the few messages the workers had already taken,
+// Stop() writes everything regardless. Pausing an already paused logger does nothing.
+func Pause() {
+	std.Pause()
+}
+
+// Resume lets the workers write again after Pause(). Does nothing if the logger is not paused.
+func Resume() {
+	std.Resume()
+}
+
+// Paused reports whether the logger is paused, see Pause().
+func Paused() bool {
+	return std.Paused()
+}
+
+// Pause stops the workers from writing until Resume() is called. See Pause().
+func (l *Logger) Pause() {
+	g := &l.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused.Load() {
+		return
+	}
+	g.resumed = make(chan struct{})
+	g.paused.Store(true)
+}
+
+// Resume lets the workers write again after Pause(). See Resume().
+func (l *Logger) Resume() {
+	g := &l.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused.Load() {
+		return
+	}
+	g.paused.Store(false)
+	close(g.resumed)
+	g.resumed = nil
+}
+
+// Paused reports whether the logger is paused. See Paused().
+func (l *Logger) Paused() bool {
+	return l.gate.paused.Load()
+}
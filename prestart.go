@@ -0,0 +1,95 @@
+package asynclog
+
+import (
+	"os"
+	"sync"
+)
+
+// PreStartPolicy decides what happens to messages sent while the logger is not started.
+type PreStartPolicy int
+
+const (
+	// DropBeforeStart ignores messages sent while the logger is not started. This is the default.
+	DropBeforeStart PreStartPolicy = iota
+	// BufferUntilStart keeps up to 1000 messages in memory and queues them when the logger starts,
+	// so initialization logs are not lost. Messages beyond that are dropped and counted by Dropped().
+	BufferUntilStart
+	// StderrBeforeStart writes messages directly to os.Stderr while the logger is not started.
+	StderrBeforeStart
+)
+
+// preStartLimit is the number of messages kept by BufferUntilStart
+const preStartLimit = 1000
+
+// preStart holds the messages sent before Start() with BufferUntilStart
+type preStart struct {
+	policy PreStartPolicy
+	mu     sync.Mutex
+	msgs   []message
+}
+
+// Sets what happens to messages sent while the logger is not started. Default is DropBeforeStart.
+//
+// Logs sent during initialization, before Start(), are silently dropped by default:
+//
+//	asynclog.SetPreStartPolicy(asynclog.BufferUntilStart)
+//	loadConfig() // logs are kept
+//	asynclog.Start() // and written now
+//
+// The policy also applies after Stop(), until the logger is started again.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetPreStartPolicy(p PreStartPolicy) {
+	std.SetPreStartPolicy(p)
+}
+
+// Sets what happens to messages sent while the logger is not started. See SetPreStartPolicy().
+func (l *Logger) SetPreStartPolicy(p PreStartPolicy) {
+	if l.isStarted {
+		return
+	}
+	l.pre.policy = p
+}
+
+// accepting reports whether messages sent now are handled rather than dropped,
+// either because the logger is started or because of the pre-start policy.
+func (l *Logger) accepting() bool {
+	return l.isStarted || l.pre.policy != DropBeforeStart
+}
+
+// sendBeforeStart handles m, sent while the logger is not started, according to the pre-start policy
+func (l *Logger) sendBeforeStart(m message) {
+	switch l.pre.policy {
+	case BufferUntilStart:
+		l.pre.mu.Lock()
+		defer l.pre.mu.Unlock()
+		if len(l.pre.msgs) >= preStartLimit {
+			l.dropped.Add(1)
+			closeDone(m)
+			return
+		}
+		l.pre.msgs = append(l.pre.msgs, m)
+
+	case StderrBeforeStart:
+		os.Stderr.Write(appendLine(nil, m))
+		closeDone(m)
+	}
+}
+
+// queueBuffered queues the messages kept by BufferUntilStart, called once the workers are running
+func (l *Logger) queueBuffered() {
+	l.pre.mu.Lock()
+	msgs := l.pre.msgs
+	l.pre.msgs = nil
+	l.pre.mu.Unlock()
+
+	for _, m := range msgs {
+		if l.acquire(m) {
+			l.pushTo(l.ordered, m)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package asynclog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// byteLimit bounds the total bytes of queued but not yet written messages of a Logger
+type byteLimit struct {
+	max    int // 0 is unlimited
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queued int
+}
+
+// acquire blocks until n more bytes fit under the limit, then reserves them.
+//
+// A single message larger than the limit is let through once the queue is empty,
+// otherwise it would block forever.
+func (bl *byteLimit) acquire(n int) {
+	if bl.max <= 0 {
+		return
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if bl.cond == nil {
+		bl.cond = sync.NewCond(&bl.mu)
+	}
+	for bl.queued > 0 && bl.queued+n > bl.max {
+		bl.cond.Wait()
+	}
+	bl.queued += n
+}
+
+// tryAcquire reserves n bytes if they fit under the limit, without waiting.
+// Like acquire, a message larger than the limit fits once the queue is empty.
+func (bl *byteLimit) tryAcquire(n int) bool {
+	if bl.max <= 0 {
+		return true
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if bl.queued > 0 && bl.queued+n > bl.max {
+		return false
+	}
+	bl.queued += n
+	return true
+}
+
+// release returns n bytes written by a worker
+func (bl *byteLimit) release(n int) {
+	if bl.max <= 0 || n == 0 {
+		return
+	}
+	bl.mu.Lock()
+	bl.queued -= n
+	if bl.cond != nil {
+		bl.cond.Broadcast()
+	}
+	bl.mu.Unlock()
+}
+
+// Sets the maximum total bytes of messages queued but not yet written. Default is 0, unlimited.
+//
+// SetBuffer() limits the number of queued messages, which does not bound memory when message
+// sizes vary wildly. Once n bytes are queued, sending a message blocks until the workers
+// have written enough to make room for it, like sending to a full buffer does.
+// With the DropNewest or DropOldest policy the message is dropped instead, see SetDropPolicy().
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetMaxQueuedBytes(n int) {
+	std.SetMaxQueuedBytes(n)
+}
+
+// Sets the maximum total bytes of messages queued but not yet written. See SetMaxQueuedBytes().
+func (l *Logger) SetMaxQueuedBytes(n int) {
+	if l.isStarted {
+		return
+	}
+	l.queue.max = n
+}
+
+// highWater calls a function when the number of queued messages crosses a threshold
+type highWater struct {
+	threshold int
+	fn        func(depth int)
+	above     atomic.Bool
+}
+
+// check calls fn once when depth goes above the threshold. It is called again
+// only after the queue has drained back to the threshold.
+func (hw *highWater) check(depth int) {
+	if hw.fn == nil {
+		return
+	}
+	if depth <= hw.threshold {
+		if hw.above.Load() {
+			hw.above.Store(false)
+		}
+		return
+	}
+	if hw.above.CompareAndSwap(false, true) {
+		go hw.fn(depth)
+	}
+}
+
+// SetHighWaterCallback calls fn when more than threshold messages are queued, an early
+// warning that the workers can't keep up before sending messages starts to block.
+//
+// fn receives the number of queued messages and runs on its own goroutine. It is called
+// once each time the queue crosses the threshold, not for every message while it stays above.
+//
+//	asynclog.SetHighWaterCallback(80, func(depth int) {
+//		metrics.Inc("log_backlog_high")
+//	})
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetHighWaterCallback(threshold int, fn func(depth int)) {
+	std.SetHighWaterCallback(threshold, fn)
+}
+
+// SetHighWaterCallback calls fn when more than threshold messages are queued. See SetHighWaterCallback().
+func (l *Logger) SetHighWaterCallback(threshold int, fn func(depth int)) {
+	if l.isStarted {
+		return
+	}
+	l.highWater.threshold = threshold
+	l.highWater.fn = fn
+}
@@ -0,0 +1,70 @@
+package asynclog
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimit is a token bucket shared by the workers of a Logger, see SetGlobalRateLimit()
+type rateLimit struct {
+	perSec float64 // 0 is unlimited
+	block  bool    // wait for a token instead of dropping
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait before writing the message,
+// or ok false if the message has to be dropped.
+func (rl *rateLimit) reserve() (wait time.Duration, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	t := now()
+	if rl.last.IsZero() {
+		rl.tokens = rl.perSec
+	} else {
+		rl.tokens = min(rl.tokens+t.Sub(rl.last).Seconds()*rl.perSec, rl.perSec)
+	}
+	rl.last = t
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0, true
+	}
+	if !rl.block {
+		return 0, false
+	}
+	// Borrow the token, the following messages wait for the debt to be paid back
+	rl.tokens--
+	return time.Duration(-rl.tokens / rl.perSec * float64(time.Second)), true
+}
+
+// SetGlobalRateLimit caps the messages written to at most linesPerSec per second, all workers
+// together, to protect a shared sink from a misbehaving process. Default is 0, unlimited.
+//
+// Bursts of up to linesPerSec messages are written right away. Beyond the rate, what happens
+// to the excess follows the drop policy, see SetDropPolicy():
+//   - Block, the default, delays the messages: the workers wait, the buffer fills up and
+//     sending eventually blocks, nothing is lost
+//   - DropNewest and DropOldest drop the excess messages and count them in Dropped()
+//
+// Only messages taken by the workers count, messages sent with PrintTo() included.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetGlobalRateLimit(linesPerSec int) {
+	std.SetGlobalRateLimit(linesPerSec)
+}
+
+// SetGlobalRateLimit caps the messages written to at most linesPerSec per second. See SetGlobalRateLimit().
+func (l *Logger) SetGlobalRateLimit(linesPerSec int) {
+	if l.isStarted {
+		return
+	}
+	l.limit.perSec = float64(max(linesPerSec, 0))
+}
@@ -0,0 +1,111 @@
+package asynclog
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a message in structured form, passed to a RecordWriter instead of a formatted line.
+type Record struct {
+	Time   time.Time
+	Level  Level  // InfoLevel for Print() and the other messages without a level
+	File   string // file and line of the caller for Debug() and DebugHere(), empty otherwise
+	Line   int
+	Msg    string  // the message without level tag
+	Fields []Field // the fields of Log(), Entry.Field(), With() and WithContext(), redacted by RedactKeys()
+}
+
+// RecordWriter receives structured records instead of formatted lines, see SetRecordWriter().
+type RecordWriter interface {
+	WriteRecord(Record) error
+}
+
+// recordSink is the RecordWriter of a Logger, called by one worker at a time
+type recordSink struct {
+	mu sync.Mutex
+	w  RecordWriter
+}
+
+func (rs *recordSink) write(rec Record) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.w.WriteRecord(rec)
+}
+
+// SetRecordWriter sends every message to rw as a Record instead of writing formatted lines to the output,
+// for sinks that need the level, caller and fields separately, like a database or a JSON encoder:
+//
+//	type jsonSink struct{ enc *json.Encoder }
+//
+//	func (s jsonSink) WriteRecord(r asynclog.Record) error {
+//		return s.enc.Encode(map[string]any{"time": r.Time, "level": r.Level.String(), "msg": r.Msg})
+//	}
+//
+//	asynclog.SetRecordWriter(jsonSink{json.NewEncoder(os.Stdout)})
+//
+// Records are passed one at a time, never concurrently, and are not batched, rw can batch them itself.
+// Messages with their own writer, sent by PrintTo() or routed by SetStderrThreshold(), are passed
+// to rw too and still written to their writer, so Error and Fatal records are not missed.
+// Errors are reported to the error handler. Tail() subscribers and the output channel still receive
+// the formatted messages, while middlewares set by Use() and the output formatting options do not apply.
+// A nil rw writes formatted lines to the output again, which is the default.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetRecordWriter(rw RecordWriter) {
+	std.SetRecordWriter(rw)
+}
+
+// SetRecordWriter sends every message to rw as a Record. See SetRecordWriter().
+func (l *Logger) SetRecordWriter(rw RecordWriter) {
+	if l.isStarted {
+		return
+	}
+	l.records.w = rw
+}
+
+// newRecord returns the record of text sent at level lvl, without its level tag,
+// or nil if the logger has no RecordWriter
+func (l *Logger) newRecord(lvl Level, text string) *Record {
+	if l.records.w == nil {
+		return nil
+	}
+	return &Record{Level: lvl, Msg: strings.TrimPrefix(text, lvl.tag())}
+}
+
+// newFieldsRecord returns the record of a Log() call, or nil if the logger has no RecordWriter
+func (l *Logger) newFieldsRecord(lvl Level, msg string, fields []Field) *Record {
+	if l.records.w == nil {
+		return nil
+	}
+	return &Record{Level: lvl, Msg: msg, Fields: slices.Clone(fields)}
+}
+
+// writeRecord passes msg to the RecordWriter instead of the output
+func (wk *worker) writeRecord(msg message) {
+	wk.publish(msg)
+	wk.passRecord(msg)
+	if wk.latency.enabled {
+		wk.latency.record(now().Sub(msg.time))
+	}
+	wk.queue.release(len(msg.text))
+	wk.rate.record(1)
+	closeDone(msg)
+}
+
+// passRecord passes the record of msg to the RecordWriter
+func (wk *worker) passRecord(msg message) {
+	rec := Record{Level: InfoLevel, Msg: msg.text}
+	if msg.rec != nil {
+		rec = *msg.rec
+	}
+	rec.Time = msg.time
+	if err := wk.records.write(rec); err != nil {
+		errorHandler(err)
+	}
+}
@@ -0,0 +1,41 @@
+package asynclog
+
+import "strings"
+
+// redactedValue replaces the values of the keys set by RedactKeys()
+const redactedValue = "***"
+
+var redactedKeys []string
+
+// RedactKeys replaces the value of every field with one of keys by "***", so secrets
+// never reach the output even when a caller logs them by mistake:
+//
+//	asynclog.RedactKeys("password", "token")
+//	asynclog.Log(asynclog.InfoLevel, "login", asynclog.String("user", "bob"), asynclog.String("Password", pw))
+//	// Output: [INFO] login user=bob Password=***
+//
+// Keys match regardless of case. It applies to every key=value pair, from Log(), With(),
+// Entry.Field(), PrintLogfmt() and PrintContext(), and to the Fields of a Record passed to a
+// RecordWriter. Calling it again adds to the keys.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func RedactKeys(keys ...string) {
+	if std.isStarted {
+		return
+	}
+	redactedKeys = append(redactedKeys, keys...)
+}
+
+// redacted reports whether the value of key is replaced, see RedactKeys()
+func redacted(key string) bool {
+	for _, k := range redactedKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package asynclog
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxReflectDepth is how deep nested structs and maps are rendered as key=value, see SetReflectFormat()
+const maxReflectDepth = 5
+
+var reflectFormat = false
+
+// Sets whether structs and maps passed to PrintArgs(), PrintLogfmt(), With() and the other
+// functions converting values are written as key=value pairs instead of Go syntax. Default is false.
+//
+//	asynclog.SetReflectFormat(true)
+//	asynclog.PrintArgs("got ", Order{ID: 7, Customer: Customer{Name: "bob"}})
+//	// Output: got ID=7 Customer.Name=bob
+//
+// See PrintStruct() for how values are rendered. Values implementing fmt.Stringer or error
+// are still written with their String() or Error() method.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetReflectFormat(b bool) {
+	if std.isStarted {
+		return
+	}
+	reflectFormat = b
+}
+
+// PrintStruct sends v to the logger as key=value pairs in logfmt, whether SetReflectFormat() is on or not:
+//
+//	asynclog.PrintStruct(map[string]any{"user": "bob", "cart": map[string]int{"apples": 3}})
+//	// Output: cart.apples=3 user=bob
+//
+// Nested structs and maps are flattened with dotted keys, up to 5 levels deep. Exported struct
+// fields are written in declaration order, map keys in sorted order, unexported fields are skipped.
+// Nil pointers are written as <nil>. Any other value is written like PrintArgs() does.
+func PrintStruct(v any) {
+	std.PrintStruct(v)
+}
+
+// PrintStruct sends v to the logger as key=value pairs in logfmt. See PrintStruct().
+func (l *Logger) PrintStruct(v any) {
+	if !l.accepting() {
+		return
+	}
+	if s, ok := reflectString(v); ok {
+		l.send(s)
+		return
+	}
+	l.send(toString(v))
+}
+
+// reflectString renders v as key=value pairs if it is a struct or a map, or a pointer to one
+func reflectString(v any) (string, bool) {
+	if _, ok := v.(fmt.Stringer); ok {
+		return "", false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct && rv.Kind() != reflect.Map {
+		return "", false
+	}
+
+	var sb strings.Builder
+	writeReflect(&sb, "", rv, 0)
+	return strings.TrimPrefix(sb.String(), " "), true
+}
+
+// writeReflect writes " key=value" to sb for every value found in rv, keys prefixed with prefix
+func writeReflect(sb *strings.Builder, prefix string, rv reflect.Value, depth int) {
+	if reflectLeaf(rv) || depth >= maxReflectDepth {
+		writeField(sb, prefix, leafString(rv))
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		writeReflect(sb, prefix, rv.Elem(), depth)
+
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				writeReflect(sb, joinKey(prefix, f.Name), rv.Field(i), depth+1)
+			}
+		}
+
+	case reflect.Map:
+		keys := rv.MapKeys()
+		names := make(map[reflect.Value]string, len(keys))
+		for _, k := range keys {
+			names[k] = leafString(k)
+		}
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(names[a], names[b])
+		})
+		for _, k := range keys {
+			writeReflect(sb, joinKey(prefix, names[k]), rv.MapIndex(k), depth+1)
+		}
+	}
+}
+
+// reflectLeaf reports whether rv is written as a single value rather than flattened
+func reflectLeaf(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return true
+		}
+	default:
+		return true
+	}
+	if !rv.CanInterface() {
+		return true
+	}
+	switch rv.Interface().(type) {
+	case fmt.Stringer, error:
+		return true
+	}
+	return false
+}
+
+// leafString converts a single value like toString(), without flattening it again
+func leafString(rv reflect.Value) string {
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return "<nil>"
+	}
+	if !rv.CanInterface() {
+		return fmt.Sprint(rv)
+	}
+	if k := rv.Kind(); k == reflect.Struct || k == reflect.Map || k == reflect.Pointer {
+		return fmt.Sprint(rv.Interface())
+	}
+	return toString(rv.Interface())
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
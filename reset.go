@@ -0,0 +1,58 @@
+package asynclog
+
+import "time"
+
+// Reset stops the default logger if it is running and restores every setting to its default,
+// so tests configuring the logger do not leak into each other:
+//
+//	func TestHandler(t *testing.T) {
+//		t.Cleanup(asynclog.Reset)
+//		asynclog.SetOutput(asynclog.NewTestWriter(t))
+//		asynclog.Start()
+//		...
+//	}
+//
+// The default logger is replaced by a new one, Loggers previously returned by Default(), With()
+// or WithContext() keep using the old one. Registered context fields and the call sites
+// remembered by PrintOnce() are cleared too. Named loggers from Get() are left as they are.
+//
+// Must not be called concurrently with logging.
+func Reset() {
+	std.Stop()
+	std = NewLogger()
+
+	newline = true
+	terminator = "\n"
+	debugCache.Clear()
+	debugCacheLimit = 0
+	debugCacheLen.Store(0)
+	includeHostPID = false
+	sequenceNumbers = false
+	debugElapsed = false
+	timestamps = false
+	timeFormat = defaultTimeFormat
+	binaryFormat = false
+	writerMaxLine = 0
+	setNow(time.Now)
+
+	compactLevel = false
+	alignLevels = false
+	errChain = false
+	errStack = true
+	stackTraceLevel = noStackTrace
+	redactedKeys = nil
+	reflectFormat = false
+	errorHandler = defaultErrorHandler
+
+	here = "Here"
+	hereLevel = DebugLevel
+	hereCounter = false
+	hereHits.Store(0)
+	hereSummary = false
+	hereSummaryHits.Store(0)
+	printedOnce.Clear()
+
+	contextFieldsMu.Lock()
+	contextFields = nil
+	contextFieldsMu.Unlock()
+}
@@ -0,0 +1,88 @@
+package asynclog
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// failoverAfter is the number of consecutive failed writes switching to the secondary output
+	failoverAfter = 3
+	// failoverProbe is how often the primary output is tried again while on the secondary one
+	failoverProbe = 5 * time.Second
+)
+
+// secondary is the output written to while the primary one fails, see SetFailoverWriter().
+// It is protected by the lock of the lockedWriter holding it.
+type secondary struct {
+	w        io.Writer // nil disables the failover
+	failures int       // consecutive failed writes to the primary output
+	active   bool      // writing to w
+	probed   time.Time // last time the primary output was tried while active
+}
+
+// write writes p to primary, or to the secondary output when primary fails.
+//
+// Only the errors of the primary output before the switch are returned, so they reach the
+// error handler, then the errors of the secondary output.
+func (sec *secondary) write(primary io.Writer, p []byte) (int, error) {
+	if sec.active {
+		if now().Sub(sec.probed) < failoverProbe {
+			return sec.w.Write(p)
+		}
+		sec.probed = now()
+		if n, err := primary.Write(p); err != nil {
+			_, serr := sec.w.Write(p[n:])
+			return len(p), serr
+		}
+		sec.active, sec.failures = false, 0
+		return len(p), nil
+	}
+
+	n, err := primary.Write(p)
+	if err == nil {
+		sec.failures = 0
+		return n, nil
+	}
+	// Nothing is lost, whatever the primary output did not take goes to the secondary one
+	sec.w.Write(p[n:])
+	sec.failures++
+	if sec.failures >= failoverAfter {
+		sec.active, sec.probed = true, now()
+	}
+	return len(p), err
+}
+
+// SetFailoverWriter sets a secondary output written to while the output set by SetOutput() fails,
+// to keep logging through an outage of a network sink:
+//
+//	backup, _ := os.OpenFile("/var/log/app/failover.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+//	asynclog.SetOutput(remoteSink)
+//	asynclog.SetFailoverWriter(backup)
+//
+// A batch the output fails to write goes to secondary. After 3 failures in a row the workers write
+// to secondary directly, and try the output again every 5 seconds with the next batch, switching
+// back as soon as it succeeds, the 5 seconds being measured on the clock set by SetClock().
+// The errors of the output are reported to the error handler until the switch, then only
+// the errors of secondary are.
+//
+// Takes precedence over SetFailoverBuffer(). A nil secondary disables the failover, which is the default.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetFailoverWriter(secondary io.Writer) {
+	std.SetFailoverWriter(secondary)
+}
+
+// SetFailoverWriter sets a secondary output written to while the output fails. See SetFailoverWriter().
+func (l *Logger) SetFailoverWriter(w io.Writer) {
+	if l.isStarted {
+		return
+	}
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.sec = secondary{w: w}
+}
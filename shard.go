@@ -0,0 +1,89 @@
+package asynclog
+
+import "math/rand/v2"
+
+// SetSharding splits the message channel into n channels, to reduce the contention of many
+// goroutines sending at once on a single channel. Default is 1, a single channel.
+//
+//	asynclog.SetWorkers(16)
+//	asynclog.SetSharding(8)
+//
+// Each message goes to a channel picked at random, without any shared state between the senders.
+// The buffer set by SetBuffer() is split between the channels, and the workers are assigned to them
+// round-robin, so n is capped to the number of workers for every channel to be drained.
+//
+// A channel can be full while others have room, in which case the drop policy applies
+// as if the whole buffer was full. Error and Fatal messages keep their own single channel.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetSharding(n int) {
+	std.SetSharding(n)
+}
+
+// SetSharding splits the message channel into n channels. See SetSharding().
+func (l *Logger) SetSharding(n int) {
+	if l.isStarted {
+		return
+	}
+	l.shardCount = n
+}
+
+// makeChannels creates the channels of the logger for the given number of workers.
+// The channels of the previous Start() are kept if the buffer and the number of shards are unchanged.
+func (l *Logger) makeChannels(workers int) {
+	if l.urgent == nil || cap(l.urgent) != l.buffer {
+		l.urgent = make(chan message, l.buffer)
+	}
+	n := min(l.shardCount, workers)
+	if n <= 1 {
+		l.shards = nil
+		if l.messages == nil || cap(l.messages) != l.buffer {
+			l.messages = make(chan message, l.buffer)
+		}
+		l.ordered = l.messages
+		return
+	}
+
+	size := (l.buffer + n - 1) / n
+	if len(l.shards) != n || cap(l.shards[0]) != size {
+		l.shards = make([]chan message, n)
+		for i := range l.shards {
+			l.shards[i] = make(chan message, size)
+		}
+	}
+	// Messages spread over the shards are taken in any order, the spilled messages and those
+	// buffered before Start() go to a single one to be written in the order they were sent
+	l.ordered = l.shards[0]
+}
+
+// shard returns the channel drained by the i-th worker
+func (l *Logger) shard(i int) chan message {
+	if l.shards == nil {
+		return l.messages
+	}
+	return l.shards[i%len(l.shards)]
+}
+
+// pick returns the channel a message is sent to
+func (l *Logger) pick() chan message {
+	if l.shards == nil {
+		return l.messages
+	}
+	return l.shards[rand.IntN(len(l.shards))]
+}
+
+// queued returns the number of messages waiting in the channels
+func (l *Logger) queued() int {
+	n := len(l.urgent)
+	if l.shards == nil {
+		return n + len(l.messages)
+	}
+	for _, ch := range l.shards {
+		n += len(ch)
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package asynclog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSIGHUP reopens the output when the process receives SIGHUP, the signal logrotate
+// and most other rotation tools send after moving a log file.
+//
+// On every SIGHUP the pending messages are flushed to the current output, the output is
+// swapped for the writer returned by reopen, and the old output is closed if it is an io.Closer.
+// os.Stdout and os.Stderr are never closed.
+//
+//	asynclog.HandleSIGHUP(func() (io.Writer, error) {
+//		return os.OpenFile("app.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+//	})
+//
+// If reopen fails the current output is kept and the error is passed to the error handler, see SetErrorHandler().
+// Safe to call before or after Start().
+func HandleSIGHUP(reopen func() (io.Writer, error)) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for range sig {
+			w, err := reopen()
+			if err != nil {
+				errorHandler(fmt.Errorf("reopening output on SIGHUP: %w", err))
+				continue
+			}
+			old, _ := std.RotateOutput(w)
+			closeOutput(old)
+		}
+	}()
+}
+
+// FlushOnSignal stops the logger when the process receives one of signals, so the last
+// messages are not lost when a program is interrupted. Defaults to os.Interrupt and SIGTERM.
+//
+//	asynclog.Start()
+//	asynclog.FlushOnSignal()
+//
+// After the pending messages are written this handler is removed and the signal is raised again,
+// so the process exits as it would have without the handler. Where a signal cannot be raised,
+// like on Windows, the process exits with status 1 instead.
+//
+// It is meant for programs without a handler of their own. A program calling signal.Notify for the
+// same signals receives the signal a second time when it is raised again, which usually cuts its
+// graceful shutdown short. Call Stop() at the end of its own shutdown instead.
+func FlushOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+
+	go func() {
+		s := <-sig
+		std.Flush()
+		std.Stop()
+
+		// Only this handler, signal.Reset would also remove the handlers of the program
+		signal.Stop(sig)
+		p, err := os.FindProcess(os.Getpid())
+		if err == nil {
+			err = p.Signal(s)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+	}()
+}
+
+// closeOutput closes w if it is an io.Closer other than os.Stdout or os.Stderr
+func closeOutput(w io.Writer) {
+	if w == os.Stdout || w == os.Stderr {
+		return
+	}
+	if c, ok := w.(io.Closer); ok {
+		c.Close()
+	}
+}
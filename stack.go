@@ -0,0 +1,137 @@
+package asynclog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// defaultStackDepth is the number of frames Stack() captures when no depth is given
+const defaultStackDepth = 32
+
+// Stack sends msg to the logger followed by the stack trace of the calling goroutine,
+// one frame per line:
+//
+//	asynclog.Stack("unexpected state")
+//	// Output:
+//	// unexpected state
+//	//	main.process
+//	//		/app/main.go:42
+//	//	main.main
+//	//		/app/main.go:17
+//
+// Capturing a stack is much heavier than Debug(), so it is an explicit call.
+// depth optionally limits the number of frames, 32 by default.
+func Stack(msg string, depth ...int) {
+	std.stack(1, msg, depth...)
+}
+
+// Stack sends msg to the logger followed by the stack trace of the calling goroutine. See Stack().
+func (l *Logger) Stack(msg string, depth ...int) {
+	l.stack(1, msg, depth...)
+}
+
+// stack sends msg followed by the stack trace, skip frames above the caller of stack.
+func (l *Logger) stack(skip int, msg string, depth ...int) {
+	if !l.accepting() {
+		return
+	}
+	n := defaultStackDepth
+	if len(depth) > 0 && depth[0] > 0 {
+		n = depth[0]
+	}
+	l.send(msg + "\n" + stackTrace(skip+1, n))
+}
+
+// stackTrace formats at most depth frames of the current goroutine, skip frames above the caller of stackTrace.
+func stackTrace(skip, depth int) string {
+	pcs := make([]uintptr, depth)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		writeFrame(&sb, frame)
+		if !more {
+			break
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// writeFrame writes frame as a function line followed by an indented file:line line
+func writeFrame(sb *strings.Builder, frame runtime.Frame) {
+	sb.WriteByte('\t')
+	sb.WriteString(frame.Function)
+	sb.WriteString("\n\t\t")
+	sb.WriteString(frame.File)
+	sb.WriteByte(':')
+	sb.WriteString(strconv.Itoa(frame.Line))
+}
+
+// pkgPrefix prefixes the names of the functions of this package, see callerStackTrace()
+const pkgPrefix = "github.com/ninesl/asynclog-go."
+
+// callerStackTrace formats at most depth frames of the current goroutine, starting at the
+// first frame outside of this package, so the trace starts at the code that sent the message
+// whichever logging function it called.
+func callerStackTrace(depth int) string {
+	pcs := make([]uintptr, depth+16)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for n := 0; n < depth; {
+		frame, more := frames.Next()
+		if n > 0 || !strings.HasPrefix(frame.Function, pkgPrefix) {
+			if n > 0 {
+				sb.WriteByte('\n')
+			}
+			writeFrame(&sb, frame)
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// noStackTrace is above every level, no message gets a stack trace
+const noStackTrace = FatalLevel + 1
+
+// stackTraceLevel is the level from which messages get a stack trace, see SetStackTraceLevel()
+var stackTraceLevel = noStackTrace
+
+// Sets the minimum level of messages that get the stack trace of the calling goroutine appended,
+// like Stack() does. Default is none, no level gets a stack trace.
+//
+//	asynclog.SetStackTraceLevel(asynclog.ErrorLevel)
+//	asynclog.Error("payment failed")
+//	// Output:
+//	// [ERROR] payment failed
+//	//	main.charge
+//	//		/app/payment.go:88
+//	//	main.main
+//	//		/app/main.go:17
+//
+// Traces are limited to 32 frames and start at the code calling the logger.
+// Print() and other messages without a level never get one, and ErrorWithStack() keeps its own.
+// The fields of With() stay on the first line, before the trace.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetStackTraceLevel(l Level) {
+	if std.isStarted {
+		return
+	}
+	stackTraceLevel = l
+}
@@ -0,0 +1,123 @@
+package asynclog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// restartLimit bounds how often the workers of a Logger are restarted after a panic
+type restartLimit struct {
+	max    int // restarts allowed per window, 0 disables restarting
+	window time.Duration
+	mu     sync.Mutex
+	times  []time.Time // restarts within the last window
+}
+
+// allow reports whether one more restart fits in the limit, and records it if so
+func (rl *restartLimit) allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	t := now()
+	recent := rl.times[:0]
+	for _, rt := range rl.times {
+		if t.Sub(rt) < rl.window {
+			recent = append(recent, rt)
+		}
+	}
+	rl.times = recent
+	if len(rl.times) >= rl.max {
+		return false
+	}
+	rl.times = append(rl.times, t)
+	return true
+}
+
+// Sets how many times per window the workers are restarted after a panic. Default is 5 per minute.
+//
+// A worker panics when the output does, for example a misbehaving io.Writer. The panic is
+// recovered, reported to the error handler and the worker starts over, losing the batch it was
+// writing. Once the logger restarts more than n workers within per, the next worker to panic
+// exits for good instead of crash looping. LiveWorkers() shows how many are left.
+// With no worker left, messages are no longer written and sending blocks once the buffer is full.
+//
+// An n of 0 never restarts a worker. A worker blocked forever by its output is not detected.
+//
+// Has to be called before
+//
+//	Start()
+//
+// If the logger is already started, this function does nothing.
+func SetWorkerRestarts(n int, per time.Duration) {
+	std.SetWorkerRestarts(n, per)
+}
+
+// Sets how many times per window the workers are restarted after a panic. See SetWorkerRestarts().
+func (l *Logger) SetWorkerRestarts(n int, per time.Duration) {
+	if l.isStarted {
+		return
+	}
+	l.restarts.max = n
+	l.restarts.window = per
+}
+
+// LiveWorkers returns the number of workers currently running.
+//
+// It is equal to SetWorkers() while the logger is healthy, lower once workers have
+// exited after too many restarts, and 0 when the logger is not started.
+func LiveWorkers() int {
+	return std.LiveWorkers()
+}
+
+// LiveWorkers returns the number of workers currently running. See LiveWorkers().
+func (l *Logger) LiveWorkers() int {
+	return int(l.live.Load())
+}
+
+// supervise runs wk until the logger is stopped, restarting it after a panic.
+// The worker is counted as live by start().
+func (l *Logger) supervise(wk *worker) {
+	defer func() {
+		l.live.Add(-1)
+		close(wk.done)
+	}()
+
+	for {
+		err := wk.run()
+		if err == nil {
+			return
+		}
+		if !l.restarts.allow() {
+			errorHandler(fmt.Errorf("%w, too many restarts, worker exiting", err))
+			return
+		}
+		errorHandler(err)
+	}
+}
+
+// run consumes messages until the logger is stopped, recovering a panic into an error
+func (wk *worker) run() (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err = fmt.Errorf("worker panicked: %v", r)
+
+		// The batch is lost, give back its bytes and start over with a clean writer
+		wk.queue.release(wk.pending + wk.curSize)
+		wk.pending, wk.curSize = 0, 0
+		wk.cur = message{}
+		wk.count = 0
+		wk.buf = wk.buf[:0]
+		wk.sent = wk.sent[:0]
+		wk.w.Reset(wk.out)
+		if wk.reply != nil {
+			wk.reply <- err
+			wk.reply = nil
+		}
+	}()
+	wk.consumeMessages()
+	return nil
+}
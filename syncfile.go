@@ -0,0 +1,75 @@
+package asynclog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// syncFileWriter is a file synced to disk periodically, see NewSyncFileWriter()
+type syncFileWriter struct {
+	*os.File
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSyncFileWriter opens the file at path for appending, creating it if needed, and calls
+// its Sync method every syncInterval and on Close, so the log survives a power loss.
+//
+//	fw, err := asynclog.NewSyncFileWriter("/var/log/app/audit.log", time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	asynclog.SetOutput(fw)
+//	asynclog.Start()
+//	defer fw.Close()
+//	defer asynclog.Stop()
+//
+// The workers flush their batches to the operating system, which may keep them in its page cache
+// for a while. Sync forces them to disk, at the cost of an fsync per interval. Messages written
+// since the last sync can still be lost. Sync errors are reported to the error handler.
+func NewSyncFileWriter(path string, syncInterval time.Duration) (io.WriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	sw := &syncFileWriter{
+		File: f,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go sw.syncLoop(syncInterval)
+	return sw, nil
+}
+
+func (sw *syncFileWriter) syncLoop(interval time.Duration) {
+	defer close(sw.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := sw.Sync(); err != nil {
+				errorHandler(err)
+			}
+		case <-sw.stop:
+			return
+		}
+	}
+}
+
+// Close stops the periodic sync, syncs the file a last time and closes it.
+// Calling Close more than once returns the error of closing a closed file.
+func (sw *syncFileWriter) Close() error {
+	sw.once.Do(func() {
+		close(sw.stop)
+		<-sw.done
+	})
+	if err := sw.Sync(); err != nil {
+		sw.File.Close()
+		return err
+	}
+	return sw.File.Close()
+}
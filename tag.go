@@ -0,0 +1,59 @@
+package asynclog
+
+import "sync/atomic"
+
+// tagFilter holds the tags written by PrintTagged(), nil writes every tag
+type tagFilter struct {
+	tags atomic.Pointer[map[string]struct{}]
+}
+
+func (tf *tagFilter) allows(tag string) bool {
+	tags := tf.tags.Load()
+	if tags == nil {
+		return true
+	}
+	_, ok := (*tags)[tag]
+	return ok
+}
+
+// PrintTagged sends msg to the logger prefixed with tag in braces, a lightweight category
+// that is easy to filter downstream:
+//
+//	asynclog.PrintTagged("db", "connection pool exhausted") // Output: {db} connection pool exhausted
+//
+// Messages with a tag not allowed by SetTagFilter() are dropped before anything is formatted.
+func PrintTagged(tag, msg string) {
+	std.PrintTagged(tag, msg)
+}
+
+// Sets the tags written by PrintTagged(), the other tagged messages are dropped.
+// Calling it without tags writes every tag again, which is the default.
+//
+//	asynclog.SetTagFilter("db", "http") // only {db} and {http} messages
+//
+// Like SetLevel(), it can be called while the logger is running, to turn the logging of a
+// subsystem on and off. Messages sent with Print() and the other functions are never filtered.
+func SetTagFilter(tags ...string) {
+	std.SetTagFilter(tags...)
+}
+
+// PrintTagged sends msg to the logger prefixed with tag in braces. See PrintTagged().
+func (l *Logger) PrintTagged(tag, msg string) {
+	if !l.accepting() || !l.tagFilter.allows(tag) {
+		return
+	}
+	l.send("{" + tag + "} " + msg)
+}
+
+// Sets the tags written by PrintTagged(). See SetTagFilter().
+func (l *Logger) SetTagFilter(tags ...string) {
+	if len(tags) == 0 {
+		l.tagFilter.tags.Store(nil)
+		return
+	}
+	m := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		m[tag] = struct{}{}
+	}
+	l.tagFilter.tags.Store(&m)
+}
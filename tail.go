@@ -0,0 +1,75 @@
+package asynclog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// tapBuffer is the number of messages a Tail() subscriber can fall behind before messages are dropped
+const tapBuffer = 256
+
+// taps are the Tail() subscribers of a Logger
+type taps struct {
+	mu   sync.RWMutex
+	subs map[chan string]struct{}
+	n    atomic.Int32 // len(subs), checked by the workers without locking
+}
+
+// broadcast sends msg to every subscriber, dropping it for the ones that are full
+func (t *taps) broadcast(msg string) {
+	if t.n.Load() == 0 {
+		return
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for ch := range t.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+func (t *taps) subscribe() (<-chan string, func()) {
+	ch := make(chan string, tapBuffer)
+
+	t.mu.Lock()
+	if t.subs == nil {
+		t.subs = map[chan string]struct{}{}
+	}
+	t.subs[ch] = struct{}{}
+	t.n.Add(1)
+	t.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.subs, ch)
+			t.n.Add(-1)
+			t.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Tail returns a channel receiving every message written by the logger, and a function to unsubscribe.
+//
+// Useful for in-process log viewers. Messages are sent without the trailing newline.
+// A subscriber that falls behind by more than 256 messages misses messages instead of
+// slowing down the logger. Calling cancel closes the channel, it is safe to call more than once.
+//
+//	tail, cancel := asynclog.Tail()
+//	defer cancel()
+//	for msg := range tail {
+//		dashboard.Append(msg)
+//	}
+func Tail() (<-chan string, func()) {
+	return std.Tail()
+}
+
+// Tail returns a channel receiving every message written by the logger. See Tail().
+func (l *Logger) Tail() (<-chan string, func()) {
+	return l.taps.subscribe()
+}
@@ -0,0 +1,56 @@
+package asynclog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// TestLogger is the part of testing.TB used by NewTestWriter(), so the package does not import testing.
+// *testing.T, *testing.B and *testing.F satisfy it.
+type TestLogger interface {
+	Log(args ...any)
+	Cleanup(f func())
+}
+
+// testWriter forwards every line written to it to t.Log
+type testWriter struct {
+	mu   sync.Mutex
+	t    TestLogger
+	done bool
+}
+
+// NewTestWriter returns an io.Writer that forwards each written line to t.Log,
+// so the output is attributed to the test and only shown on failure or with -v.
+//
+//	func TestSomething(t *testing.T) {
+//		asynclog.SetOutput(asynclog.NewTestWriter(t))
+//		asynclog.Start()
+//		defer asynclog.Stop()
+//		...
+//	}
+//
+// Calling t.Log after a test has completed panics, so once the test is done
+// everything written is silently dropped.
+func NewTestWriter(t TestLogger) io.Writer {
+	tw := &testWriter{t: t}
+	t.Cleanup(func() {
+		tw.mu.Lock()
+		tw.done = true
+		tw.mu.Unlock()
+	})
+	return tw
+}
+
+func (tw *testWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.done {
+		return len(p), nil
+	}
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte{'\n'}), []byte{'\n'}) {
+		tw.t.Log(string(line))
+	}
+	return len(p), nil
+}
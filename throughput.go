@@ -0,0 +1,70 @@
+package asynclog
+
+import (
+	"sync"
+	"time"
+)
+
+// throughputWindow is the number of seconds Throughput() averages over
+const throughputWindow = 10
+
+// throughput counts the messages written by the workers of a Logger per second, over the last throughputWindow seconds
+type throughput struct {
+	mu      sync.Mutex
+	seconds [throughputWindow]int64 // unix second counted by each slot
+	counts  [throughputWindow]uint64
+}
+
+// record counts n messages written now
+func (tp *throughput) record(n int) {
+	if n == 0 {
+		return
+	}
+	sec := now().Unix()
+	i := sec % throughputWindow
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.seconds[i] != sec {
+		tp.seconds[i] = sec
+		tp.counts[i] = 0
+	}
+	tp.counts[i] += uint64(n)
+}
+
+// Throughput returns the messages written per second over the last 10 seconds.
+//
+// Shows the real world logging rate of a running program, not just the one of a benchmark.
+// A logger started less than 10 seconds ago is averaged over its uptime. Cheap enough
+// to call on every status line:
+//
+//	asynclog.Infof("uptime: %s, %.0f msg/s", asynclog.Uptime().Round(time.Second), asynclog.Throughput())
+//
+// Messages sent to io.Discard are not written and not counted. Returns 0 if the logger is not started.
+func Throughput() float64 {
+	return std.Throughput()
+}
+
+// Throughput returns the messages written per second over the last 10 seconds. See Throughput().
+func (l *Logger) Throughput() float64 {
+	if !l.isStarted {
+		return 0
+	}
+	t := now()
+	window := min(t.Sub(l.startTime), throughputWindow*time.Second)
+	if window <= 0 {
+		return 0
+	}
+
+	sec := t.Unix()
+	tp := &l.throughput
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	var total uint64
+	for i, s := range tp.seconds {
+		if sec-s < throughputWindow {
+			total += tp.counts[i]
+		}
+	}
+	return float64(total) / window.Seconds()
+}
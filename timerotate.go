@@ -0,0 +1,83 @@
+package asynclog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// timeRotatingWriter writes to a file named after the current period, see NewTimeRotatingWriter()
+type timeRotatingWriter struct {
+	pattern  string
+	interval time.Duration
+
+	mu     sync.Mutex
+	f      *os.File
+	period time.Time // start of the period of f
+}
+
+// NewTimeRotatingWriter returns an io.WriteCloser writing to a new file every interval, for hourly
+// or daily log files. The file name is the start of the period formatted with the last element of
+// pattern, a time layout, the directory is used as is:
+//
+//	w := asynclog.NewTimeRotatingWriter("/var/log/app/app-2006-01-02-15.log", time.Hour)
+//	asynclog.SetOutput(w)
+//	asynclog.Start()
+//	defer w.Close()
+//	defer asynclog.Stop()
+//	// writes /var/log/app/app-2024-01-02-15.log, then app-2024-01-02-16.log...
+//
+// Periods start at multiples of interval since the zero time, in UTC, so daily files start at
+// midnight UTC. Files are opened for appending and created if needed, a file from a previous
+// run of the same period is continued.
+//
+// The file is switched by the first write of a new period, the old file is closed then.
+// The workers write whole batches of lines, so no line is split between two files, though
+// lines sent just before the boundary can land in the new file. Errors opening a file are
+// returned by Write and reported to the error handler, the next write tries again.
+func NewTimeRotatingWriter(pattern string, interval time.Duration) io.WriteCloser {
+	return &timeRotatingWriter{pattern: pattern, interval: max(interval, time.Second)}
+}
+
+func (tw *timeRotatingWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	period := now().UTC().Truncate(tw.interval)
+	if tw.f == nil || !period.Equal(tw.period) {
+		if err := tw.rotate(period); err != nil {
+			return 0, err
+		}
+	}
+	return tw.f.Write(p)
+}
+
+// rotate closes the current file and opens the one of period
+func (tw *timeRotatingWriter) rotate(period time.Time) error {
+	name := filepath.Join(filepath.Dir(tw.pattern), period.Format(filepath.Base(tw.pattern)))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if tw.f != nil {
+		if err := tw.f.Close(); err != nil {
+			errorHandler(err)
+		}
+	}
+	tw.f, tw.period = f, period
+	return nil
+}
+
+// Close closes the current file. Writing after Close opens a file again.
+func (tw *timeRotatingWriter) Close() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.f == nil {
+		return nil
+	}
+	err := tw.f.Close()
+	tw.f = nil
+	return err
+}
@@ -0,0 +1,36 @@
+package asynclog
+
+// noop is returned by TraceFunc() when TraceLevel is filtered
+func noop() {}
+
+// TraceFunc sends "→ name" at TraceLevel and returns a function sending "← name" with the
+// time elapsed since, to trace the entry and exit of a function in one line:
+//
+//	func processOrder(id int) {
+//		defer asynclog.TraceFunc("processOrder")()
+//		...
+//	}
+//	// Output:
+//	// [TRACE] → processOrder
+//	// [TRACE] ← processOrder (12.5ms)
+//
+// It is not named Trace, which sends a single message, see Trace().
+// If TraceLevel is filtered when it is called, nothing is sent on entry nor on exit and the returned
+// function does nothing, enable it with SetLevel(TraceLevel).
+func TraceFunc(name string) func() {
+	return std.TraceFunc(name)
+}
+
+// TraceFunc sends "→ name" at TraceLevel and returns a function sending "← name" with the elapsed time. See TraceFunc().
+func (l *Logger) TraceFunc(name string) func() {
+	if !l.enabled(TraceLevel) {
+		return noop
+	}
+	start := now()
+	l.sendLevel(TraceLevel, TraceLevel.tag()+"→ "+name)
+	return func() {
+		if l.enabled(TraceLevel) {
+			l.sendLevel(TraceLevel, TraceLevel.tag()+"← "+name+" ("+now().Sub(start).String()+")")
+		}
+	}
+}
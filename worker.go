@@ -0,0 +1,421 @@
+package asynclog
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	batchSize     = 256       // Batch bytes per queued message, see consumeMessages()
+	bufferSize    = 1024 * 64 // 64KB buffer
+	flushInterval = 500 * time.Millisecond
+)
+
+// worker consumes messages from the channel of a Logger into its own batch buffer.
+type worker struct {
+	messages <-chan message
+	urgent   <-chan message
+	stop     <-chan struct{}    // closed by Stop()
+	flush    chan chan error    // Flush() requests
+	reply    chan error         // the Flush() being answered, see run()
+	idle     chan chan struct{} // WaitIdle() requests
+	waiters  []chan struct{}    // WaitIdle() requests answered by the next write()
+	done     chan struct{}      // closed when the worker exits for good
+	discards bool
+	determ   bool
+	out      *lockedWriter // output of the Logger
+	queue    *byteLimit    // queued bytes of the Logger
+	taps     *taps         // Tail() subscribers of the Logger
+	outChan  chan<- string
+	dropped  *atomic.Uint64
+	hw       *highWater
+	gate     *pauseGate
+	latency  *latencyHistogram
+	rate     *throughput
+	limit    *rateLimit
+	records  *recordSink
+	toMu     *sync.Mutex // serializes the writes of writeTo()
+	sent     []time.Time // send times of the messages in buf, for the latency histogram
+	w        *bufio.Writer
+	buf      []byte
+	pending  int // bytes of the messages in buf
+	count    int // messages in buf
+
+	bufSize int  // batch buffer size, smaller under a memory budget
+	shrink  bool // release buf when idle
+
+	pipe    func(string) // the middlewares of the Logger ending with addSized(), see Use()
+	cur     message      // message going through pipe
+	curSize int          // queued bytes of cur, accounted by the first line pipe emits
+}
+
+func newWorker(l *Logger, messages <-chan message) *worker {
+	wk := &worker{
+		messages: messages,
+		urgent:   l.urgent,
+		stop:     l.stop,
+		flush:    make(chan chan error),
+		idle:     make(chan chan struct{}),
+		done:     make(chan struct{}),
+		discards: l.out.w == io.Discard && l.records.w == nil,
+		determ:   l.determ,
+		out:      &l.out,
+		queue:    &l.queue,
+		taps:     &l.taps,
+		outChan:  l.outChan,
+		dropped:  &l.dropped,
+		hw:       &l.highWater,
+		gate:     &l.gate,
+		latency:  &l.latency,
+		rate:     &l.throughput,
+		limit:    &l.limit,
+		records:  &l.records,
+		toMu:     &l.toMu,
+		bufSize:  l.workerBuffer(),
+		shrink:   l.budget > 0,
+	}
+	wk.w = bufio.NewWriterSize(&l.out, wk.bufSize)
+	if len(l.middlewares) > 0 {
+		wk.pipe = chain(l.middlewares, wk.emit)
+	}
+	return wk
+}
+
+// TODO: more improvements
+func (wk *worker) consumeMessages() {
+	// Nothing is written to io.Discard, skip the batching and just drain the channel.
+	// Useful for benchmarking code with logging turned on but without the I/O cost.
+	// Decided once at Start(), swapping the output later does not change it.
+	if wk.discards {
+		wk.discard()
+		return
+	}
+
+	// Pre-allocate buffer
+	wk.buf = make([]byte, 0, wk.bufSize)
+
+	timer := time.NewTimer(flushInterval)
+	defer timer.Stop()
+	tick := timer.C
+	if wk.determ {
+		// Only write full buffers, on Flush() and on Stop(), see SetDeterministic()
+		tick = nil
+	}
+
+	for {
+		if resumed := wk.gate.wait(); resumed != nil {
+			// Paused, leave the messages in the channel until Resume(), see Pause()
+			select {
+			case <-resumed:
+			case reply := <-wk.flush:
+				wk.answer(reply)
+			case <-wk.stop:
+				wk.drain()
+				return
+			}
+			continue
+		}
+
+		// Urgent messages are taken before anything else and written right away
+		select {
+		case msg := <-wk.urgent:
+			wk.add(msg)
+			wk.write()
+			timer.Reset(flushInterval)
+			continue
+		default:
+		}
+
+		select {
+		case msg := <-wk.urgent:
+			wk.add(msg)
+			if !wk.gate.paused.Load() {
+				wk.write()
+				timer.Reset(flushInterval)
+			}
+
+		case msg := <-wk.messages:
+			wk.hw.check(len(wk.messages))
+			wk.add(msg)
+
+			// Adapt the batch to the backlog. An empty queue is written right away for low
+			// latency, a backed up queue keeps batching up to bufferSize for throughput.
+			if len(wk.buf) >= wk.batchLimit() && !wk.gate.paused.Load() {
+				wk.write()
+				timer.Reset(flushInterval)
+			}
+
+		case <-tick:
+			wk.write()
+			if wk.shrink {
+				// Idle, give the batch buffer back until the next message, see SetMemoryBudget()
+				wk.buf = nil
+			}
+			wk.out.retry()
+			timer.Reset(flushInterval)
+
+		case reply := <-wk.flush:
+			wk.answer(reply)
+
+		case done := <-wk.idle:
+			if len(wk.buf) == 0 {
+				close(done)
+			} else {
+				wk.waiters = append(wk.waiters, done)
+			}
+
+		case <-wk.stop:
+			wk.drain()
+			return
+		}
+	}
+}
+
+// answer writes the batch buffer for a Flush() request and replies with the error
+func (wk *worker) answer(reply chan error) {
+	wk.reply = reply
+	err := wk.write()
+	wk.reply = nil
+	reply <- err
+}
+
+// drain writes whatever is left in the channels once the logger is stopped,
+// the other workers are doing the same.
+func (wk *worker) drain() {
+	for {
+		select {
+		case msg := <-wk.urgent:
+			wk.add(msg)
+			continue
+		default:
+		}
+		select {
+		case msg := <-wk.messages:
+			wk.add(msg)
+		default:
+			wk.write()
+			return
+		}
+	}
+}
+
+// batchLimit returns the size at which the batch buffer is written, see consumeMessages()
+func (wk *worker) batchLimit() int {
+	if wk.determ {
+		return wk.bufSize
+	}
+	return min(batchSize*len(wk.messages), wk.bufSize)
+}
+
+// add runs msg through the middlewares, if any, then appends it to the batch buffer
+// or writes it right away if it has its own writer
+func (wk *worker) add(msg message) {
+	if wk.limit.perSec > 0 && !wk.throttle(msg) {
+		return
+	}
+	if wk.records.w != nil {
+		if msg.w == nil {
+			wk.writeRecord(msg)
+			return
+		}
+		// Still written to its own writer below, see SetRecordWriter()
+		wk.passRecord(msg)
+	}
+	if wk.pipe == nil {
+		wk.addSized(msg, len(msg.text))
+		return
+	}
+	wk.cur, wk.curSize = msg, len(msg.text)
+	wk.pipe(msg.text)
+	if wk.curSize > 0 {
+		// Dropped by a middleware
+		wk.queue.release(wk.curSize)
+		closeDone(wk.cur)
+	}
+	wk.cur = message{}
+}
+
+// throttle applies SetGlobalRateLimit() to msg, returning false if it is dropped.
+// Delayed messages wait after the batch buffer is written, so it does not wait with them.
+func (wk *worker) throttle(msg message) bool {
+	wait, ok := wk.limit.reserve()
+	if !ok {
+		wk.dropped.Add(1)
+		wk.queue.release(len(msg.text))
+		closeDone(msg)
+		return false
+	}
+	if wait > 0 {
+		wk.write()
+		time.Sleep(wait)
+	}
+	return true
+}
+
+// emit is the end of the middleware chain, adding a line made from the current message
+func (wk *worker) emit(text string) {
+	m := wk.cur
+	m.text = text
+	size := wk.curSize
+	wk.curSize = 0
+	// A message split by a middleware is done with its first line, which is written with the others
+	wk.cur.done = nil
+	wk.addSized(m, size)
+}
+
+// addSized is add for a message that takes size bytes of the queue
+func (wk *worker) addSized(msg message, size int) {
+	wk.publish(msg)
+	if msg.w != nil {
+		wk.writeTo(msg, size)
+		return
+	}
+	if msg.done != nil {
+		wk.waiters = append(wk.waiters, msg.done)
+	}
+	wk.pending += size
+	wk.count++
+	if wk.latency.enabled {
+		wk.sent = append(wk.sent, msg.time)
+	}
+	wk.buf = appendLine(wk.buf, msg)
+}
+
+// publish sends msg to the Tail() subscribers and the output channel, never blocking on either
+func (wk *worker) publish(msg message) {
+	wk.taps.broadcast(msg.text)
+	if wk.outChan == nil {
+		return
+	}
+	line := msg.text
+	if timestamps {
+		line = msg.time.Format(timeFormat) + " " + line
+	}
+	select {
+	case wk.outChan <- line:
+	default:
+		wk.dropped.Add(1)
+	}
+}
+
+// appendLine appends msg to dst as it is written to the output
+func appendLine(dst []byte, msg message) []byte {
+	if binaryFormat {
+		return appendFrame(dst, msg)
+	}
+	start := len(dst)
+	if timestamps {
+		dst = msg.time.AppendFormat(dst, timeFormat)
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, msg.text...)
+	dst = truncateLine(dst, start)
+	if newline {
+		dst = append(dst, terminator...)
+	}
+	return dst
+}
+
+// truncateLine cuts the line starting at dst[start] to writerMaxLine bytes, see SetWriterMaxLine().
+// It never cuts a UTF-8 character in half.
+func truncateLine(dst []byte, start int) []byte {
+	if writerMaxLine <= 0 || len(dst)-start <= writerMaxLine {
+		return dst
+	}
+	end := start + writerMaxLine
+	for end > start && !utf8.RuneStart(dst[end]) {
+		end--
+	}
+	return dst[:end]
+}
+
+// write writes and flushes the batch buffer to the output, returning the first error.
+//
+// Errors are also reported to the error handler, see SetErrorHandler().
+func (wk *worker) write() error {
+	var err error
+	if len(wk.buf) > 0 {
+		_, err = wk.w.Write(wk.buf)
+		wk.buf = wk.buf[:0]
+		wk.queue.release(wk.pending)
+		wk.pending = 0
+		wk.rate.record(wk.count)
+		wk.count = 0
+		for _, t := range wk.sent {
+			wk.latency.record(now().Sub(t))
+		}
+		wk.sent = wk.sent[:0]
+	}
+	if ferr := wk.w.Flush(); err == nil {
+		err = ferr
+	}
+	for _, done := range wk.waiters {
+		close(done)
+	}
+	wk.waiters = wk.waiters[:0]
+	if err != nil {
+		// bufio.Writer keeps returning the same error once a write failed, start over
+		wk.w.Reset(wk.out)
+		errorHandler(err)
+	}
+	return err
+}
+
+// writeTo writes a single message directly to its own writer, see PrintTo()
+func (wk *worker) writeTo(msg message, size int) {
+	wk.toMu.Lock()
+	_, err := msg.w.Write(appendLine(nil, msg))
+	wk.toMu.Unlock()
+	if err != nil {
+		errorHandler(err)
+	}
+	if wk.latency.enabled {
+		wk.latency.record(now().Sub(msg.time))
+	}
+	wk.queue.release(size)
+	wk.rate.record(1)
+	closeDone(msg)
+}
+
+// skip drops msg without writing it, for discard(). Messages with their own writer are still written.
+func (wk *worker) skip(msg message) {
+	if msg.w != nil {
+		wk.publish(msg)
+		wk.writeTo(msg, len(msg.text))
+		return
+	}
+	wk.publish(msg)
+	wk.queue.release(len(msg.text))
+	closeDone(msg)
+}
+
+// discard drains the channel without writing anything, Tail() subscribers still receive every message
+func (wk *worker) discard() {
+	for {
+		select {
+		case msg := <-wk.urgent:
+			wk.skip(msg)
+		case msg := <-wk.messages:
+			wk.skip(msg)
+		case reply := <-wk.flush:
+			reply <- nil
+		case done := <-wk.idle:
+			close(done)
+		case <-wk.stop:
+			for {
+				select {
+				case msg := <-wk.urgent:
+					wk.skip(msg)
+				case msg := <-wk.messages:
+					wk.skip(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
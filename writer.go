@@ -0,0 +1,91 @@
+package asynclog
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrNotStarted is returned when writing to a logger that is not started, and by StopErr().
+var ErrNotStarted = errors.New("logger not started")
+
+// logWriter adapts a Logger to io.Writer and io.StringWriter
+type logWriter struct {
+	l *Logger
+}
+
+// Writer returns an io.Writer that sends every Write to the logger as one message.
+//
+// It also implements io.StringWriter, so io.WriteString() and other callers checking for it
+// send strings directly without the []byte round trip. A trailing newline is trimmed since
+// the workers append their own. Handy to plug the logger into APIs taking an io.Writer:
+//
+//	log.SetOutput(asynclog.Writer())
+//
+// Writes return ErrNotStarted while the logger is stopped, unless a SetPreStartPolicy() keeps them.
+func Writer() io.Writer {
+	return std.Writer()
+}
+
+// Writer returns an io.Writer that sends every Write to the logger as one message. See Writer().
+func (l *Logger) Writer() io.Writer {
+	return logWriter{l: l}
+}
+
+// WriteCloser returns an io.WriteCloser that sends every Write to the logger like Writer(),
+// and flushes and stops the logger on Close.
+//
+// Suitable for defer patterns and APIs taking an io.WriteCloser:
+//
+//	w := asynclog.WriteCloser()
+//	defer w.Close()
+//
+// Close returns the error of flushing the output, if any. Only the first Close
+// stops the logger, the following ones do nothing and return nil.
+func WriteCloser() io.WriteCloser {
+	return std.WriteCloser()
+}
+
+// WriteCloser returns an io.WriteCloser that stops the logger on Close. See WriteCloser().
+func (l *Logger) WriteCloser() io.WriteCloser {
+	return &closingWriter{logWriter: logWriter{l: l}}
+}
+
+// closingWriter is a logWriter that stops the logger when closed
+type closingWriter struct {
+	logWriter
+	once sync.Once
+}
+
+func (cw *closingWriter) Close() error {
+	var err error
+	cw.once.Do(func() {
+		err = cw.l.Flush()
+		cw.l.Stop()
+	})
+	return err
+}
+
+func (lw logWriter) Write(p []byte) (int, error) {
+	if !lw.l.accepting() {
+		return 0, ErrNotStarted
+	}
+	lw.l.send(string(trimNewline(p)))
+	return len(p), nil
+}
+
+func (lw logWriter) WriteString(s string) (int, error) {
+	if !lw.l.accepting() {
+		return 0, ErrNotStarted
+	}
+	lw.l.send(strings.TrimSuffix(s, "\n"))
+	return len(s), nil
+}
+
+func trimNewline(p []byte) []byte {
+	if len(p) > 0 && p[len(p)-1] == '\n' {
+		return p[:len(p)-1]
+	}
+	return p
+}